@@ -0,0 +1,465 @@
+package genome
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AgpRecord is a single line from an AGP v2.1 file. See
+// https://www.ncbi.nlm.nih.gov/assembly/agp/AGP_Specification/
+//
+// Columns 6-9 mean different things for gap lines (ComponentType N or
+// U) and component lines (all other types) so AgpRecord has fields for
+// both and only the relevant set is filled in. All coordinates are
+// 1-based closed intervals.
+type AgpRecord struct {
+	Object        string
+	ObjectBeg     int
+	ObjectEnd     int
+	PartNumber    int
+	ComponentType string
+
+	// Component lines
+	ComponentId  string
+	ComponentBeg int
+	ComponentEnd int
+	Orientation  string
+
+	// Gap lines
+	GapLength       int
+	GapType         string
+	Linkage         string
+	LinkageEvidence string
+
+	LineNumber int // Line number within the AGP file
+}
+
+// Agp is the contents of an AGP file. Header holds the comment lines,
+// including the ##agp-version line, with their # prefixes.
+type Agp struct {
+	File    string
+	Header  []string
+	Records []*AgpRecord
+}
+
+// NewAgp returns an empty Agp with an AGP v2.1 version header.
+func NewAgp() *Agp {
+	return &Agp{Header: []string{"##agp-version\t2.1"}}
+}
+
+// IsGap reports whether the record describes a gap rather than a
+// component.
+func (a *AgpRecord) IsGap() bool {
+	return a.ComponentType == `N` || a.ComponentType == `U`
+}
+
+// Length is the number of bases the record covers in the object.
+func (a *AgpRecord) Length() int {
+	return a.ObjectEnd - a.ObjectBeg + 1
+}
+
+// NewAgpRecordFromLine parses a single non-comment AGP line.
+func NewAgpRecordFromLine(line string) (*AgpRecord, error) {
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	fields := strings.Split(line, "\t")
+	if len(fields) != 9 {
+		return nil, fmt.Errorf("genome.NewAgpRecordFromLine: %d fields supplied - 9 are required", len(fields))
+	}
+
+	ints := make([]int, 3)
+	for i, col := range []int{1, 2, 3} {
+		v, err := strconv.Atoi(fields[col])
+		if err != nil {
+			return nil, fmt.Errorf("genome.NewAgpRecordFromLine: column %d is not an integer: %w", col+1, err)
+		}
+		ints[i] = v
+	}
+	a := &AgpRecord{
+		Object:        fields[0],
+		ObjectBeg:     ints[0],
+		ObjectEnd:     ints[1],
+		PartNumber:    ints[2],
+		ComponentType: fields[4],
+	}
+	if a.ObjectBeg < 1 || a.ObjectEnd < a.ObjectBeg {
+		return nil, fmt.Errorf("genome.NewAgpRecordFromLine: invalid object coordinates %d-%d", a.ObjectBeg, a.ObjectEnd)
+	}
+
+	if a.IsGap() {
+		gl, err := strconv.Atoi(fields[5])
+		if err != nil {
+			return nil, fmt.Errorf("genome.NewAgpRecordFromLine: gap length is not an integer: %w", err)
+		}
+		a.GapLength = gl
+		a.GapType = fields[6]
+		a.Linkage = fields[7]
+		a.LinkageEvidence = fields[8]
+		if a.GapLength != a.Length() {
+			return nil, fmt.Errorf("genome.NewAgpRecordFromLine: gap length %d does not match object length %d", a.GapLength, a.Length())
+		}
+		return a, nil
+	}
+
+	a.ComponentId = fields[5]
+	cb, err := strconv.Atoi(fields[6])
+	if err != nil {
+		return nil, fmt.Errorf("genome.NewAgpRecordFromLine: component_beg is not an integer: %w", err)
+	}
+	ce, err := strconv.Atoi(fields[7])
+	if err != nil {
+		return nil, fmt.Errorf("genome.NewAgpRecordFromLine: component_end is not an integer: %w", err)
+	}
+	a.ComponentBeg = cb
+	a.ComponentEnd = ce
+	a.Orientation = fields[8]
+	if a.ComponentBeg < 1 || a.ComponentEnd < a.ComponentBeg {
+		return nil, fmt.Errorf("genome.NewAgpRecordFromLine: invalid component coordinates %d-%d", cb, ce)
+	}
+	if a.ComponentEnd-a.ComponentBeg+1 != a.Length() {
+		return nil, fmt.Errorf("genome.NewAgpRecordFromLine: component length %d does not match object length %d",
+			a.ComponentEnd-a.ComponentBeg+1, a.Length())
+	}
+	return a, nil
+}
+
+// String returns the record as a tab-separated AGP line without a line
+// ending.
+func (a *AgpRecord) String() string {
+	cols := []string{a.Object,
+		strconv.Itoa(a.ObjectBeg),
+		strconv.Itoa(a.ObjectEnd),
+		strconv.Itoa(a.PartNumber),
+		a.ComponentType}
+	if a.IsGap() {
+		cols = append(cols,
+			strconv.Itoa(a.GapLength),
+			a.GapType,
+			a.Linkage,
+			a.LinkageEvidence)
+	} else {
+		cols = append(cols,
+			a.ComponentId,
+			strconv.Itoa(a.ComponentBeg),
+			strconv.Itoa(a.ComponentEnd),
+			a.Orientation)
+	}
+	return strings.Join(cols, "\t")
+}
+
+// NewAgpFromFile reads an AGP file. It will handle gzipped files as
+// long as they have a .gz extension.
+func NewAgpFromFile(file string) (*Agp, error) {
+	ff, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer ff.Close()
+
+	var scanner *bufio.Scanner
+
+	found, err := regexp.MatchString(`\.[gG][zZ]$`, file)
+	if err != nil {
+		return nil, fmt.Errorf("genome.NewAgpFromFile: error matching gzip file pattern against %s: %w", file, err)
+	}
+	if found {
+		reader, err := gzip.NewReader(ff)
+		if err != nil {
+			return nil, fmt.Errorf("genome.NewAgpFromFile: error opening gzip file %s: %w", file, err)
+		}
+		defer reader.Close()
+		scanner = bufio.NewScanner(reader)
+	} else {
+		scanner = bufio.NewScanner(ff)
+	}
+
+	agp, err := NewAgpFromScanner(scanner)
+	if err != nil {
+		return nil, fmt.Errorf("genome.NewAgpFromFile: error reading %s: %w", file, err)
+	}
+	agp.File = file
+	return agp, nil
+}
+
+// NewAgpFromScanner reads AGP lines from a *bufio.Scanner. Empty lines
+// are skipped and lines starting with # are kept in Header.
+func NewAgpFromScanner(scanner *bufio.Scanner) (*Agp, error) {
+	agp := &Agp{}
+	scanner.Split(bufio.ScanLines)
+
+	lctr := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lctr++
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			agp.Header = append(agp.Header, line)
+			continue
+		}
+		rec, err := NewAgpRecordFromLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lctr, err)
+		}
+		rec.LineNumber = lctr
+		agp.Records = append(agp.Records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return agp, nil
+}
+
+// Write writes the Agp to file.
+func (agp *Agp) Write(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	for _, h := range agp.Header {
+		if _, err := w.WriteString(h + "\n"); err != nil {
+			return err
+		}
+	}
+	for _, r := range agp.Records {
+		if _, err := w.WriteString(r.String() + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Objects returns the names of the objects (scaffolds, chromosomes)
+// in the order they first appear in the Agp.
+func (agp *Agp) Objects() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range agp.Records {
+		if !seen[r.Object] {
+			seen[r.Object] = true
+			names = append(names, r.Object)
+		}
+	}
+	return names
+}
+
+// NewAgpFromGenome describes each sequence in the Genome as an object
+// made of contigs separated by gaps, where a gap is any run of at
+// least minGap Ns. Contigs are named after their object with a _1, _2,
+// ... suffix and gaps are recorded as N type scaffold gaps with
+// linkage evidence unspecified. The contig sequences themselves can be
+// produced with Agp.BuildComponents.
+func NewAgpFromGenome(g *Genome, minGap int) (*Agp, error) {
+	agp := NewAgp()
+	if minGap < 1 {
+		minGap = 1
+	}
+	err := g.eachSequence(func(r *FastaRec) error {
+		part := 0
+		contig := 0
+		pos := 0
+		addContig := func(beg, end int) {
+			part++
+			contig++
+			agp.Records = append(agp.Records, &AgpRecord{
+				Object:        r.Name,
+				ObjectBeg:     beg + 1,
+				ObjectEnd:     end,
+				PartNumber:    part,
+				ComponentType: `W`,
+				ComponentId:   fmt.Sprintf("%s_%d", r.Name, contig),
+				ComponentBeg:  1,
+				ComponentEnd:  end - beg,
+				Orientation:   `+`,
+			})
+		}
+		for _, run := range nRuns(r.Sequence, minGap) {
+			if run[0] > pos {
+				addContig(pos, run[0])
+			}
+			part++
+			agp.Records = append(agp.Records, &AgpRecord{
+				Object:          r.Name,
+				ObjectBeg:       run[0] + 1,
+				ObjectEnd:       run[1],
+				PartNumber:      part,
+				ComponentType:   `N`,
+				GapLength:       run[1] - run[0],
+				GapType:         `scaffold`,
+				Linkage:         `yes`,
+				LinkageEvidence: `unspecified`,
+			})
+			pos = run[1]
+		}
+		if pos < len(r.Sequence) {
+			addContig(pos, len(r.Sequence))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("genome.NewAgpFromGenome: %w", err)
+	}
+	return agp, nil
+}
+
+// BuildObjects assembles the object sequences described by the Agp
+// from the component sequences in components. Components with
+// orientation - are reverse complemented and gaps are filled with Ns.
+// The returned Genome has one FastaRec per object in the order the
+// objects appear in the Agp.
+func (agp *Agp) BuildObjects(components *Genome) (*Genome, error) {
+	g := NewGenome(agp.File)
+	builders := make(map[string]*strings.Builder)
+	for _, name := range agp.Objects() {
+		builders[name] = &strings.Builder{}
+	}
+
+	for _, r := range agp.Records {
+		b := builders[r.Object]
+		if b.Len() != r.ObjectBeg-1 {
+			return g, fmt.Errorf("genome.Agp.BuildObjects: line %d: %s starts at %d but previous part ends at %d",
+				r.LineNumber, r.Object, r.ObjectBeg, b.Len())
+		}
+		if r.IsGap() {
+			b.WriteString(strings.Repeat("N", r.GapLength))
+			continue
+		}
+		c, err := components.GetSequence(r.ComponentId)
+		if err != nil {
+			return g, fmt.Errorf("genome.Agp.BuildObjects: line %d: %w", r.LineNumber, err)
+		}
+		if r.ComponentEnd > c.Length() {
+			return g, fmt.Errorf("genome.Agp.BuildObjects: line %d: component %s end %d is beyond its length %d",
+				r.LineNumber, r.ComponentId, r.ComponentEnd, c.Length())
+		}
+		seq := c.Sequence[r.ComponentBeg-1 : r.ComponentEnd]
+		if r.Orientation == `-` {
+			seq = string(reverseComplement([]byte(seq)))
+		}
+		b.WriteString(seq)
+	}
+
+	for _, name := range agp.Objects() {
+		fr := NewFastaRec(">" + name)
+		fr.Sequence = builders[name].String()
+		g.Sequences = append(g.Sequences, fr)
+	}
+	return g, nil
+}
+
+// BuildComponents extracts the component sequences described by the
+// Agp from the object sequences in objects, reversing the effect of
+// BuildObjects. A component that is only partly used by the Agp is
+// named ComponentId:ComponentBeg-ComponentEnd.
+func (agp *Agp) BuildComponents(objects *Genome) (*Genome, error) {
+	g := NewGenome(agp.File)
+	for _, r := range agp.Records {
+		if r.IsGap() {
+			continue
+		}
+		o, err := objects.GetSequence(r.Object)
+		if err != nil {
+			return g, fmt.Errorf("genome.Agp.BuildComponents: line %d: %w", r.LineNumber, err)
+		}
+		if r.ObjectEnd > o.Length() {
+			return g, fmt.Errorf("genome.Agp.BuildComponents: line %d: object %s end %d is beyond its length %d",
+				r.LineNumber, r.Object, r.ObjectEnd, o.Length())
+		}
+		seq := o.Sequence[r.ObjectBeg-1 : r.ObjectEnd]
+		if r.Orientation == `-` {
+			seq = string(reverseComplement([]byte(seq)))
+		}
+		name := r.ComponentId
+		if r.ComponentBeg != 1 {
+			name = fmt.Sprintf("%s:%d-%d", r.ComponentId, r.ComponentBeg, r.ComponentEnd)
+		}
+		fr := NewFastaRec(">" + name)
+		fr.Sequence = seq
+		g.Sequences = append(g.Sequences, fr)
+	}
+	return g, nil
+}
+
+// Verify checks the Agp against the object sequences in objects. It
+// checks that every object exists and is exactly covered by its parts
+// in order, that every gap is all Ns and, if components is not nil,
+// that every component sequence matches the object sequence. All of
+// the problems found are returned joined into a single error.
+func (agp *Agp) Verify(objects *Genome, components *Genome) error {
+	var errs []error
+	ends := make(map[string]int)
+	for _, r := range agp.Records {
+		o, err := objects.GetSequence(r.Object)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", r.LineNumber, err))
+			continue
+		}
+		if r.ObjectBeg != ends[r.Object]+1 {
+			errs = append(errs, fmt.Errorf("line %d: %s part starts at %d but previous part ends at %d",
+				r.LineNumber, r.Object, r.ObjectBeg, ends[r.Object]))
+		}
+		ends[r.Object] = r.ObjectEnd
+		if r.ObjectEnd > o.Length() {
+			errs = append(errs, fmt.Errorf("line %d: %s part ends at %d beyond sequence length %d",
+				r.LineNumber, r.Object, r.ObjectEnd, o.Length()))
+			continue
+		}
+		seq := o.Sequence[r.ObjectBeg-1 : r.ObjectEnd]
+
+		if r.IsGap() {
+			if strings.Trim(seq, "Nn") != "" {
+				errs = append(errs, fmt.Errorf("line %d: %s gap %d-%d is not all N",
+					r.LineNumber, r.Object, r.ObjectBeg, r.ObjectEnd))
+			}
+			continue
+		}
+
+		if components == nil {
+			continue
+		}
+		c, err := components.GetSequence(r.ComponentId)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", r.LineNumber, err))
+			continue
+		}
+		if r.ComponentEnd > c.Length() {
+			errs = append(errs, fmt.Errorf("line %d: component %s end %d is beyond its length %d",
+				r.LineNumber, r.ComponentId, r.ComponentEnd, c.Length()))
+			continue
+		}
+		cseq := c.Sequence[r.ComponentBeg-1 : r.ComponentEnd]
+		if r.Orientation == `-` {
+			cseq = string(reverseComplement([]byte(cseq)))
+		}
+		if !strings.EqualFold(seq, cseq) {
+			errs = append(errs, fmt.Errorf("line %d: component %s does not match %s:%d-%d",
+				r.LineNumber, r.ComponentId, r.Object, r.ObjectBeg, r.ObjectEnd))
+		}
+	}
+
+	for _, name := range agp.Objects() {
+		end := ends[name]
+		o, err := objects.GetSequence(name)
+		if err == nil && end != o.Length() {
+			errs = append(errs, fmt.Errorf("%s is %d bases long but the AGP only covers %d",
+				name, o.Length(), end))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("genome.Agp.Verify: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package genome
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAgpRoundTrip(t *testing.T) {
+	g := NewGenome("testing")
+	r1 := NewFastaRec(">scaf1")
+	r1.Sequence = `ACGTACGTNNNNNggccaaTTNNNNNA`
+	r2 := NewFastaRec(">scaf2")
+	r2.Sequence = `ACGTTT`
+	g.Sequences = append(g.Sequences, r1, r2)
+
+	agp, err := NewAgpFromGenome(g, 3)
+	if err != nil {
+		t.Fatalf(`NewAgpFromGenome failed: %v`, err)
+	}
+	if len(agp.Records) != 6 {
+		t.Fatalf(`Agp should have 6 records but has %d`, len(agp.Records))
+	}
+	e1 := "scaf1\t9\t13\t2\tN\t5\tscaffold\tyes\tunspecified"
+	if g1 := agp.Records[1].String(); e1 != g1 {
+		t.Fatalf(`Agp record 1 should be [%s] but is [%s]`, e1, g1)
+	}
+	e2 := "scaf1\t14\t21\t3\tW\tscaf1_2\t1\t8\t+"
+	if g2 := agp.Records[2].String(); e2 != g2 {
+		t.Fatalf(`Agp record 2 should be [%s] but is [%s]`, e2, g2)
+	}
+
+	// Write and read back
+	file := filepath.Join(t.TempDir(), "test.agp")
+	if err := agp.Write(file); err != nil {
+		t.Fatalf(`Agp.Write failed: %v`, err)
+	}
+	agp2, err := NewAgpFromFile(file)
+	if err != nil {
+		t.Fatalf(`NewAgpFromFile failed: %v`, err)
+	}
+	if len(agp2.Records) != len(agp.Records) || agp2.Header[0] != "##agp-version\t2.1" {
+		t.Fatalf(`Agp read back incorrectly: %d records`, len(agp2.Records))
+	}
+
+	// Components out and objects back in should be lossless
+	comps, err := agp2.BuildComponents(g)
+	if err != nil {
+		t.Fatalf(`BuildComponents failed: %v`, err)
+	}
+	if len(comps.Sequences) != 4 || comps.Sequences[1].Sequence != `ggccaaTT` {
+		t.Fatalf(`BuildComponents incorrect: %d sequences`, len(comps.Sequences))
+	}
+	objs, err := agp2.BuildObjects(comps)
+	if err != nil {
+		t.Fatalf(`BuildObjects failed: %v`, err)
+	}
+	for i, r := range g.Sequences {
+		if objs.Sequences[i].Name != r.Name || objs.Sequences[i].Sequence != r.Sequence {
+			t.Fatalf(`BuildObjects sequence %d should be %s but is %s`,
+				i, r.Sequence, objs.Sequences[i].Sequence)
+		}
+	}
+	if err := agp2.Verify(g, comps); err != nil {
+		t.Fatalf(`Verify should have passed: %v`, err)
+	}
+
+	// Reverse orientation
+	agp2.Records[2].Orientation = `-`
+	if err := agp2.Verify(g, comps); err == nil {
+		t.Fatalf(`Verify should have failed on a reversed component`)
+	}
+	objs, err = agp2.BuildObjects(comps)
+	if err != nil {
+		t.Fatalf(`BuildObjects failed: %v`, err)
+	}
+	if !strings.Contains(objs.Sequences[0].Sequence, `AAttggcc`) {
+		t.Fatalf(`BuildObjects did not reverse complement: %s`, objs.Sequences[0].Sequence)
+	}
+
+	// Drop the last contig of scaf1 so it is not fully covered
+	agp.Records = append(agp.Records[:4], agp.Records[5])
+	if err := agp.Verify(g, nil); err == nil {
+		t.Fatalf(`Verify should have failed on an uncovered object`)
+	}
+}
+
+func TestNewAgpRecordFromLine(t *testing.T) {
+	bad := []string{
+		"chr1\t1\t10\t1\tW\tctg1\t1\t9\t+",
+		"chr1\t1\t10\t1\tN\t9\tscaffold\tyes\tpaired-ends",
+		"chr1\t1\t10\t1\tW\tctg1\t1\t10",
+		"chr1\tx\t10\t1\tW\tctg1\t1\t10\t+",
+	}
+	for _, l := range bad {
+		if _, err := NewAgpRecordFromLine(l); err == nil {
+			t.Fatalf(`NewAgpRecordFromLine should have failed on [%s]`, l)
+		}
+	}
+}
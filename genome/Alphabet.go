@@ -0,0 +1,82 @@
+package genome
+
+import (
+	"fmt"
+)
+
+// Alphabet identifies the set of characters that may appear in a
+// Sequence. All alphabets are case-insensitive.
+type Alphabet int
+
+const (
+	UnknownAlphabet Alphabet = iota
+	DNA                      // ACGT plus N
+	RNA                      // ACGU plus N
+	IUPAC                    // all IUPAC nucleotide codes, T or U
+	Protein                  // IUPAC amino acid codes plus * for stop
+)
+
+var alphabetNames = map[Alphabet]string{
+	UnknownAlphabet: "unknown",
+	DNA:             "DNA",
+	RNA:             "RNA",
+	IUPAC:           "IUPAC",
+	Protein:         "protein",
+}
+
+var alphabetChars = map[Alphabet]string{
+	DNA:     "ACGTN",
+	RNA:     "ACGUN",
+	IUPAC:   "ACGTURYSWKMBDHVN",
+	Protein: "ACDEFGHIKLMNPQRSTVWYBZJUOX*",
+}
+
+// alphabetTables holds a lookup table per Alphabet so that validating
+// a genome-scale sequence is a single array index per base.
+var alphabetTables = map[Alphabet]*[256]bool{}
+
+func init() {
+	for a, chars := range alphabetChars {
+		var t [256]bool
+		for i := 0; i < len(chars); i++ {
+			t[chars[i]] = true
+			if chars[i] >= 'A' && chars[i] <= 'Z' {
+				t[chars[i]+('a'-'A')] = true
+			}
+		}
+		alphabetTables[a] = &t
+	}
+}
+
+func (a Alphabet) String() string {
+	if s, ok := alphabetNames[a]; ok {
+		return s
+	}
+	return alphabetNames[UnknownAlphabet]
+}
+
+// AlphabetFromString returns the Alphabet with the given name as
+// returned by Alphabet.String. The match is exact.
+func AlphabetFromString(s string) (Alphabet, error) {
+	for a, name := range alphabetNames {
+		if s == name && a != UnknownAlphabet {
+			return a, nil
+		}
+	}
+	return UnknownAlphabet, fmt.Errorf("genome.AlphabetFromString: unknown alphabet: %s", s)
+}
+
+// invalidPosition returns the 0-based offset of the first character in
+// s that is not in Alphabet a or -1 if every character is valid.
+func (a Alphabet) invalidPosition(s string) int {
+	t, ok := alphabetTables[a]
+	if !ok {
+		return 0
+	}
+	for i := 0; i < len(s); i++ {
+		if !t[s[i]] {
+			return i
+		}
+	}
+	return -1
+}
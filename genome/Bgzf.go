@@ -0,0 +1,116 @@
+package genome
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// BGZF is the blocked gzip format used by bgzip, samtools and htslib.
+// A BGZF file is a series of gzip members, each holding at most 64KB
+// of data and recording its own compressed size in a gzip extra field,
+// followed by an empty end-of-file member. Any gzip reader can read it
+// but it can also be indexed for random access.
+
+// bgzfBlockSize is the most uncompressed data that bgzip puts in a
+// block. It leaves room for incompressible data to grow and still fit
+// in a 64KB block.
+const bgzfBlockSize = 0xff00
+
+// bgzfEOF is the empty block that marks the end of a BGZF file.
+var bgzfEOF = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00,
+	0x42, 0x43, 0x02, 0x00, 0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+}
+
+// bgzfWriter compresses data written to it into BGZF blocks.
+type bgzfWriter struct {
+	w    io.Writer
+	buf  []byte
+	cbuf bytes.Buffer
+	fw   *flate.Writer
+	err  error
+}
+
+func newBgzfWriter(w io.Writer, level int) (*bgzfWriter, error) {
+	fw, err := flate.NewWriter(nil, level)
+	if err != nil {
+		return nil, err
+	}
+	return &bgzfWriter{w: w, fw: fw,
+		buf: make([]byte, 0, bgzfBlockSize)}, nil
+}
+
+func (bw *bgzfWriter) Write(p []byte) (int, error) {
+	if bw.err != nil {
+		return 0, bw.err
+	}
+	n := 0
+	for len(p) > 0 {
+		c := copy(bw.buf[len(bw.buf):cap(bw.buf)], p)
+		bw.buf = bw.buf[:len(bw.buf)+c]
+		p = p[c:]
+		n += c
+		if len(bw.buf) == cap(bw.buf) {
+			if err := bw.writeBlock(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// writeBlock compresses and writes the buffered data as one block.
+func (bw *bgzfWriter) writeBlock() error {
+	if len(bw.buf) == 0 {
+		return nil
+	}
+	bw.cbuf.Reset()
+	bw.fw.Reset(&bw.cbuf)
+	if _, err := bw.fw.Write(bw.buf); err != nil {
+		bw.err = err
+		return err
+	}
+	if err := bw.fw.Close(); err != nil {
+		bw.err = err
+		return err
+	}
+
+	cdata := bw.cbuf.Bytes()
+	hdr := []byte{0x1f, 0x8b, 0x08, 0x04, 0, 0, 0, 0, 0, 0xff, 0x06, 0x00,
+		'B', 'C', 0x02, 0x00, 0, 0}
+	binary.LittleEndian.PutUint16(hdr[16:], uint16(len(hdr)+len(cdata)+8-1))
+	var tail [8]byte
+	binary.LittleEndian.PutUint32(tail[0:], crc32.ChecksumIEEE(bw.buf))
+	binary.LittleEndian.PutUint32(tail[4:], uint32(len(bw.buf)))
+
+	for _, b := range [][]byte{hdr, cdata, tail[:]} {
+		if _, err := bw.w.Write(b); err != nil {
+			bw.err = err
+			return err
+		}
+	}
+	bw.buf = bw.buf[:0]
+	return nil
+}
+
+// Flush writes any buffered data as a (short) block.
+func (bw *bgzfWriter) Flush() error {
+	if bw.err != nil {
+		return bw.err
+	}
+	return bw.writeBlock()
+}
+
+// Close writes any buffered data and the end-of-file block. It does
+// not close the underlying io.Writer.
+func (bw *bgzfWriter) Close() error {
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	_, err := bw.w.Write(bgzfEOF)
+	return err
+}
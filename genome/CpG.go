@@ -0,0 +1,187 @@
+package genome
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// CpGOptions holds the criteria for calling CpG islands. Window is the
+// width of the sliding window that is scored at every position. A
+// window passes if its GC fraction is at least MinGC and its observed
+// to expected CpG ratio is at least MinObsExp, where
+//
+//	obs/exp = CpG count * length / (C count * G count)
+//
+// Overlapping passing windows are merged, the merged region is trimmed
+// to start and end with a CpG, and it is reported as an island if it
+// is at least MinLength long and, taken as a whole, still passes the
+// GC and obs/exp criteria.
+type CpGOptions struct {
+	Window    int
+	MinLength int
+	MinGC     float64
+	MinObsExp float64
+}
+
+// NewGardinerGardenOptions returns the criteria from Gardiner-Garden M
+// and Frommer M (1987) J Mol Biol 196:261-282: at least 200 bases with
+// GC of at least 50% and obs/exp CpG of at least 0.6.
+func NewGardinerGardenOptions() *CpGOptions {
+	return &CpGOptions{Window: 200, MinLength: 200, MinGC: 0.5, MinObsExp: 0.6}
+}
+
+// NewTakaiJonesOptions returns the stricter criteria from Takai D and
+// Jones PA (2002) PNAS 99:3740-3745 which exclude most Alu repeats: at
+// least 500 bases with GC of at least 55% and obs/exp CpG of at least
+// 0.65.
+func NewTakaiJonesOptions() *CpGOptions {
+	return &CpGOptions{Window: 200, MinLength: 500, MinGC: 0.55, MinObsExp: 0.65}
+}
+
+// cpgCounts holds the C, G and CpG counts for a stretch of sequence.
+type cpgCounts struct {
+	c, g, cg int
+}
+
+func (cc cpgCounts) passes(length int, opts *CpGOptions) bool {
+	if length == 0 || cc.c == 0 || cc.g == 0 {
+		return false
+	}
+	gc := float64(cc.c+cc.g) / float64(length)
+	oe := float64(cc.cg*length) / float64(cc.c*cc.g)
+	return gc >= opts.MinGC && oe >= opts.MinObsExp
+}
+
+func countCpG(seq string) cpgCounts {
+	var cc cpgCounts
+	for i := 0; i < len(seq); i++ {
+		switch toUpper(seq[i]) {
+		case 'C':
+			cc.c++
+			if i+1 < len(seq) && toUpper(seq[i+1]) == 'G' {
+				cc.cg++
+			}
+		case 'G':
+			cc.g++
+		}
+	}
+	return cc
+}
+
+// cpgIslands returns the 0-based half-open limits of the CpG islands
+// in seq. The window counts are updated incrementally as the window
+// slides so memory use does not depend on the sequence length.
+func cpgIslands(seq string, opts *CpGOptions) [][2]int {
+	var islands [][2]int
+	w := opts.Window
+	if w < 2 || len(seq) < w {
+		return islands
+	}
+
+	isCpG := func(i int) bool {
+		return toUpper(seq[i]) == 'C' && toUpper(seq[i+1]) == 'G'
+	}
+
+	cc := countCpG(seq[:w])
+	start, end := -1, -1
+	for i := 0; i+w <= len(seq); i++ {
+		if i > 0 {
+			// Drop base i-1 and add base i+w-1
+			switch toUpper(seq[i-1]) {
+			case 'C':
+				cc.c--
+			case 'G':
+				cc.g--
+			}
+			if isCpG(i - 1) {
+				cc.cg--
+			}
+			switch toUpper(seq[i+w-1]) {
+			case 'C':
+				cc.c++
+			case 'G':
+				cc.g++
+			}
+			if isCpG(i + w - 2) {
+				cc.cg++
+			}
+		}
+
+		if !cc.passes(w, opts) {
+			continue
+		}
+		if start >= 0 && i <= end {
+			end = i + w
+			continue
+		}
+		if start >= 0 {
+			islands = append(islands, [2]int{start, end})
+		}
+		start, end = i, i+w
+	}
+	if start >= 0 {
+		islands = append(islands, [2]int{start, end})
+	}
+
+	// Trim the merged regions to the outermost CpGs and check that they
+	// still qualify
+	var kept [][2]int
+	for _, is := range islands {
+		for is[0] < is[1]-1 && !isCpG(is[0]) {
+			is[0]++
+		}
+		for is[1]-2 > is[0] && !isCpG(is[1]-2) {
+			is[1]--
+		}
+		l := is[1] - is[0]
+		if l >= opts.MinLength && countCpG(seq[is[0]:is[1]]).passes(l, opts) {
+			kept = append(kept, is)
+		}
+	}
+	return kept
+}
+
+// CpGIslands finds CpG islands in the FastaRec and returns a
+// gff3.Feature of Type CpG_island for each. If opts is nil, the
+// Gardiner-Garden and Frommer criteria are used. Each Feature has the
+// attributes GC (fraction), ObsExp and CpG (count).
+func (r *FastaRec) CpGIslands(opts *CpGOptions) []*gff3.Feature {
+	if opts == nil {
+		opts = NewGardinerGardenOptions()
+	}
+	var feats []*gff3.Feature
+	for _, is := range cpgIslands(r.Sequence, opts) {
+		l := is[1] - is[0]
+		cc := countCpG(r.Sequence[is[0]:is[1]])
+		f := gff3.NewFeature()
+		f.SeqId = r.Name
+		f.Source = `grz-cpg`
+		f.Type = `CpG_island`
+		f.Start = is[0] + 1
+		f.End = is[1]
+		f.Attributes[`GC`] = strconv.FormatFloat(float64(cc.c+cc.g)/float64(l), 'f', 3, 64)
+		f.Attributes[`ObsExp`] = strconv.FormatFloat(float64(cc.cg*l)/float64(cc.c*cc.g), 'f', 3, 64)
+		f.Attributes[`CpG`] = strconv.Itoa(cc.cg)
+		feats = append(feats, f)
+	}
+	return feats
+}
+
+// CpGIslands finds the CpG islands in every sequence of the Genome and
+// returns them as a sorted gff3.Features. See FastaRec.CpGIslands.
+func (g *Genome) CpGIslands(opts *CpGOptions) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(r *FastaRec) error {
+		fs.AddFeatures(r.CpGIslands(opts)...)
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.CpGIslands: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
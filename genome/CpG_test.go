@@ -0,0 +1,37 @@
+package genome
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCpGIslands(t *testing.T) {
+	r := NewFastaRec(">chrT")
+	// 300 bases of ACGCGT repeat (GC 67%, obs/exp 3) between AT-only
+	// flanks.
+	r.Sequence = strings.Repeat("AATT", 125) + strings.Repeat("ACGCGT", 50) +
+		strings.Repeat("TTAA", 125)
+
+	feats := r.CpGIslands(nil)
+	if len(feats) != 1 {
+		t.Fatalf(`CpGIslands should find 1 island but found %d`, len(feats))
+	}
+	f := feats[0]
+	if f.Start < 495 || f.Start > 505 || f.End < 795 || f.End > 805 {
+		t.Fatalf(`CpG island should be about 501-800 but is %s`, f.String())
+	}
+	if f.Type != `CpG_island` || f.Attributes[`CpG`] == `` {
+		t.Fatalf(`CpG island Feature incorrect: %s`, f.String())
+	}
+
+	// Takai-Jones needs at least 500 bases so the same island fails
+	if feats = r.CpGIslands(NewTakaiJonesOptions()); len(feats) != 0 {
+		t.Fatalf(`CpGIslands(TakaiJones) should find 0 islands but found %d`, len(feats))
+	}
+
+	// GC-rich but CpG-depleted sequence is not an island
+	r.Sequence = strings.Repeat("CCTGG", 120)
+	if feats = r.CpGIslands(nil); len(feats) != 0 {
+		t.Fatalf(`CpGIslands should find 0 islands in CCTGG repeat but found %d`, len(feats))
+	}
+}
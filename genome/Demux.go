@@ -0,0 +1,338 @@
+package genome
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SampleBarcode is a line of a sample sheet: a sample and its i7 and,
+// for dual indexing, i5 index sequences.
+type SampleBarcode struct {
+	Sample string
+	I7     string
+	I5     string
+}
+
+// UndeterminedSample is the name used for reads that match no sample.
+const UndeterminedSample = `Undetermined`
+
+// ReadSampleSheet reads the samples and barcodes from file. An Illumina
+// sample sheet is recognised by its [Data] section, where the
+// Sample_ID, index and index2 columns are used. Any other file must
+// have one sample per line with a name, an i7 and an optional i5
+// separated by whitespace or commas. Blank lines, lines starting with
+// # and rows whose fields are all empty, such as the ,,,, padding
+// written by spreadsheets, are ignored. It is an error for a sample to
+// have an empty Sample_ID or index.
+func ReadSampleSheet(file string) ([]*SampleBarcode, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []*SampleBarcode
+	var columns map[string]int // nil outside an Illumina [Data] section
+	illumina := false
+	scanner := bufio.NewScanner(f)
+	lctr := 0
+	for scanner.Scan() {
+		lctr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == `` || strings.HasPrefix(line, `#`) {
+			continue
+		}
+		if strings.HasPrefix(line, `[`) {
+			illumina = true
+			columns = nil
+			if strings.HasPrefix(strings.ToLower(line), `[data]`) {
+				columns = make(map[string]int)
+			}
+			continue
+		}
+		if illumina && columns == nil {
+			continue // a section other than [Data]
+		}
+
+		var fields []string
+		if illumina {
+			fields = strings.Split(line, `,`)
+			for i := range fields {
+				fields[i] = strings.TrimSpace(fields[i])
+			}
+		} else {
+			fields = strings.FieldsFunc(line, func(c rune) bool {
+				return c == ',' || c == ' ' || c == '\t'
+			})
+		}
+		if strings.Join(fields, ``) == `` {
+			continue
+		}
+
+		var s *SampleBarcode
+		if illumina {
+			if len(columns) == 0 {
+				for i, c := range fields {
+					columns[strings.ToLower(c)] = i
+				}
+				if _, ok := columns[`sample_id`]; !ok {
+					return nil, fmt.Errorf("genome.ReadSampleSheet: %s line %d: [Data] has no Sample_ID column", file, lctr)
+				}
+				if _, ok := columns[`index`]; !ok {
+					return nil, fmt.Errorf("genome.ReadSampleSheet: %s line %d: [Data] has no index column", file, lctr)
+				}
+				continue
+			}
+			get := func(c string) string {
+				if i, ok := columns[c]; ok && i < len(fields) {
+					return fields[i]
+				}
+				return ``
+			}
+			s = &SampleBarcode{get(`sample_id`), get(`index`), get(`index2`)}
+			if s.Sample == `` {
+				return nil, fmt.Errorf("genome.ReadSampleSheet: %s line %d has an empty Sample_ID", file, lctr)
+			}
+			if s.I7 == `` {
+				return nil, fmt.Errorf("genome.ReadSampleSheet: %s line %d has an empty index", file, lctr)
+			}
+		} else {
+			if len(fields) < 2 || len(fields) > 3 {
+				return nil, fmt.Errorf("genome.ReadSampleSheet: %s line %d should have 2 or 3 fields but has %d", file, lctr, len(fields))
+			}
+			s = &SampleBarcode{Sample: fields[0], I7: fields[1]}
+			if len(fields) == 3 {
+				s.I5 = fields[2]
+			}
+		}
+		s.I7 = strings.ToUpper(s.I7)
+		s.I5 = strings.ToUpper(s.I5)
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// DemuxOptions controls a Demultiplexer. Mismatches is the number of
+// mismatches allowed in each index. If ReverseComplementI5 is true the
+// i5 of each sample is reverse complemented before matching, as needed
+// for instruments that read i5 on the reverse strand. Files are written
+// to OutDir as <sample>_R1.fastq.gz, <sample>_R2.fastq.gz and so on
+// with the Writer options, or as <sample>_R1.fastq and so on if the
+// Writer Compression is NoCompression.
+type DemuxOptions struct {
+	Mismatches          int
+	ReverseComplementI5 bool
+	OutDir              string
+	Writer              *FastqWriterOptions
+}
+
+// NewDemuxOptions returns the bcl2fastq defaults of 1 mismatch per
+// index and writes gzipped files to the current directory.
+func NewDemuxOptions() *DemuxOptions {
+	return &DemuxOptions{Mismatches: 1, OutDir: `.`,
+		Writer: NewFastqWriterOptions()}
+}
+
+// DemuxCount is the number of reads (or pairs) assigned to a sample and
+// how many of them matched the barcodes exactly.
+type DemuxCount struct {
+	Sample  string
+	I7      string
+	I5      string
+	Reads   int
+	Perfect int
+}
+
+// Demultiplexer assigns reads to samples by their index sequences and
+// writes each sample's reads to its own FASTQ files.
+type Demultiplexer struct {
+	opts    *DemuxOptions
+	samples []*SampleBarcode
+	counts  []*DemuxCount // one per sample then Undetermined
+	writers [][]*FastqWriter
+}
+
+// NewDemultiplexer checks the samples and returns a Demultiplexer.
+// Sample names must be unique, usable as file names and not
+// Undetermined. Every sample must have the same index lengths and
+// either all or none must have an i5. It is an error for two samples
+// to be so similar that a read could match both with the allowed
+// mismatches. If opts is nil, NewDemuxOptions is used.
+func NewDemultiplexer(samples []*SampleBarcode, opts *DemuxOptions) (*Demultiplexer, error) {
+	if opts == nil {
+		opts = NewDemuxOptions()
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("genome.NewDemultiplexer: no samples")
+	}
+	d := &Demultiplexer{opts: opts}
+	names := make(map[string]bool)
+	for _, s := range samples {
+		if s.Sample == `` || s.Sample == UndeterminedSample || strings.ContainsAny(s.Sample, `/\`) {
+			return nil, fmt.Errorf("genome.NewDemultiplexer: invalid sample name %q", s.Sample)
+		}
+		if names[s.Sample] {
+			return nil, fmt.Errorf("genome.NewDemultiplexer: sample %s is listed twice", s.Sample)
+		}
+		names[s.Sample] = true
+		if len(s.I7) != len(samples[0].I7) || len(s.I5) != len(samples[0].I5) {
+			return nil, fmt.Errorf("genome.NewDemultiplexer: sample %s has different index lengths to %s", s.Sample, samples[0].Sample)
+		}
+		ns := &SampleBarcode{s.Sample, strings.ToUpper(s.I7), strings.ToUpper(s.I5)}
+		if opts.ReverseComplementI5 {
+			ns.I5 = string(reverseComplement([]byte(ns.I5)))
+		}
+		d.samples = append(d.samples, ns)
+		d.counts = append(d.counts, &DemuxCount{Sample: ns.Sample, I7: ns.I7, I5: ns.I5})
+	}
+	d.counts = append(d.counts, &DemuxCount{Sample: UndeterminedSample})
+	d.writers = make([][]*FastqWriter, len(d.counts))
+
+	// With m mismatches allowed, barcodes need to differ in more than
+	// 2m positions in at least one index.
+	for i, a := range d.samples {
+		for _, b := range d.samples[i+1:] {
+			if hamming(a.I7, b.I7) <= 2*opts.Mismatches && hamming(a.I5, b.I5) <= 2*opts.Mismatches {
+				return nil, fmt.Errorf("genome.NewDemultiplexer: barcodes of %s and %s collide with %d mismatches",
+					a.Sample, b.Sample, opts.Mismatches)
+			}
+		}
+	}
+	return d, nil
+}
+
+// hamming returns the number of positions of barcode where the index
+// read differs. Index reads longer than the barcode are compared over
+// the barcode length and N is always a mismatch.
+func hamming(barcode, index string) int {
+	d := 0
+	for i := 0; i < len(barcode); i++ {
+		if i >= len(index) || toUpper(index[i]) != barcode[i] || barcode[i] == 'N' {
+			d++
+		}
+	}
+	return d
+}
+
+// match returns the index of the matching sample, or len(samples) for
+// Undetermined, and the total mismatches.
+func (d *Demultiplexer) match(i7, i5 string) (int, int) {
+	for i, s := range d.samples {
+		m7 := hamming(s.I7, i7)
+		if m7 > d.opts.Mismatches {
+			continue
+		}
+		m5 := hamming(s.I5, i5)
+		if m5 > d.opts.Mismatches {
+			continue
+		}
+		return i, m7 + m5
+	}
+	return len(d.samples), 0
+}
+
+// Match returns the sample whose barcodes match the index sequences or
+// nil if no sample matches.
+func (d *Demultiplexer) Match(i7, i5 string) *SampleBarcode {
+	i, _ := d.match(i7, i5)
+	if i == len(d.samples) {
+		return nil
+	}
+	return d.samples[i]
+}
+
+// Write assigns the reads of one cluster, for example R1 and R2 of a
+// pair, to a sample using the index sequences i7 and i5 (from index
+// reads or a header) and writes reads[k] to the sample's R(k+1) file.
+// The files are created when the first read for a sample is written.
+// It returns the name of the sample, which may be Undetermined.
+func (d *Demultiplexer) Write(i7, i5 string, reads ...*FastqRec) (string, error) {
+	i, mm := d.match(i7, i5)
+	c := d.counts[i]
+	for len(d.writers[i]) < len(reads) {
+		file := filepath.Join(d.opts.OutDir,
+			fmt.Sprintf("%s_R%d.%s", c.Sample, len(d.writers[i])+1, d.extension()))
+		w, err := CreateFastqWriter(file, d.opts.Writer)
+		if err != nil {
+			return ``, fmt.Errorf("genome.Demultiplexer.Write: %w", err)
+		}
+		d.writers[i] = append(d.writers[i], w)
+	}
+	for k, r := range reads {
+		if err := d.writers[i][k].Write(r); err != nil {
+			return ``, fmt.Errorf("genome.Demultiplexer.Write: %w", err)
+		}
+	}
+	c.Reads++
+	if mm == 0 && i < len(d.samples) {
+		c.Perfect++
+	}
+	return c.Sample, nil
+}
+
+// extension returns the extension of the output files. AutoCompression
+// gives gzip because the extension is .gz.
+func (d *Demultiplexer) extension() string {
+	if d.opts.Writer != nil && d.opts.Writer.Compression == NoCompression {
+		return `fastq`
+	}
+	return `fastq.gz`
+}
+
+// WriteByHeader is Write with the index sequences taken from the
+// Casava 1.8 header of the first read (see ReadHeader.IndexSequences).
+// Reads without index sequences in their header are Undetermined.
+func (d *Demultiplexer) WriteByHeader(reads ...*FastqRec) (string, error) {
+	if len(reads) == 0 {
+		return ``, fmt.Errorf("genome.Demultiplexer.WriteByHeader: no reads")
+	}
+	var i7, i5 string
+	if h, err := reads[0].Header(); err == nil {
+		i7, i5 = h.IndexSequences()
+	}
+	return d.Write(i7, i5, reads...)
+}
+
+// Close closes all of the output files.
+func (d *Demultiplexer) Close() error {
+	var first error
+	for _, ws := range d.writers {
+		for _, w := range ws {
+			if err := w.Close(); err != nil && first == nil {
+				first = fmt.Errorf("genome.Demultiplexer.Close: %w", err)
+			}
+		}
+	}
+	return first
+}
+
+// Counts returns the counts for each sample, in sample sheet order,
+// followed by Undetermined.
+func (d *Demultiplexer) Counts() []*DemuxCount {
+	return d.counts
+}
+
+// Summary returns a tab-separated table of the Counts with the
+// percentage of all reads assigned to each sample.
+func (d *Demultiplexer) Summary() string {
+	total := 0
+	for _, c := range d.counts {
+		total += c.Reads
+	}
+	var b strings.Builder
+	b.WriteString("#sample\ti7\ti5\treads\tpercent\tperfect\n")
+	for _, c := range d.counts {
+		pct := 0.0
+		if total > 0 {
+			pct = 100 * float64(c.Reads) / float64(total)
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%d\t%.2f\t%d\n", c.Sample, c.I7, c.I5, c.Reads, pct, c.Perfect)
+	}
+	return b.String()
+}
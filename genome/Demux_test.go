@@ -0,0 +1,165 @@
+package genome
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSampleSheet(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		want    []SampleBarcode
+	}{
+		{"# simple\nS1\tACGTACGT\tTTGGCCAA\nS2,CCAATTGG,GGTTAACC\n\n",
+			[]SampleBarcode{{`S1`, `ACGTACGT`, `TTGGCCAA`}, {`S2`, `CCAATTGG`, `GGTTAACC`}}},
+		{"S1 acgt\n", []SampleBarcode{{`S1`, `ACGT`, ``}}},
+		{"[Header]\nIEMFileVersion,4\n\n[Reads]\n151\n\n[Data]\n" +
+			"Sample_ID,Sample_Name,index,index2\nS1,one,ACGTACGT,TTGGCCAA\nS2,two,CCAATTGG,GGTTAACC\n",
+			[]SampleBarcode{{`S1`, `ACGTACGT`, `TTGGCCAA`}, {`S2`, `CCAATTGG`, `GGTTAACC`}}},
+		// Spreadsheet padding rows
+		{"[Data]\nSample_ID,Sample_Name,index,index2\nS1,one,ACGTACGT,TTGGCCAA\n,,,\n , ,,\n",
+			[]SampleBarcode{{`S1`, `ACGTACGT`, `TTGGCCAA`}}},
+		{"S1,ACGT\n,,\n", []SampleBarcode{{`S1`, `ACGT`, ``}}},
+	}
+	for i, tt := range tests {
+		file := filepath.Join(dir, "sheet.csv")
+		if err := os.WriteFile(file, []byte(tt.content), 0644); err != nil {
+			t.Fatalf(`unable to write %s: %v`, file, err)
+		}
+		got, err := ReadSampleSheet(file)
+		if err != nil {
+			t.Fatalf(`ReadSampleSheet %d failed: %v`, i, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf(`ReadSampleSheet %d should find %d samples but found %d`, i, len(tt.want), len(got))
+		}
+		for j := range got {
+			if *got[j] != tt.want[j] {
+				t.Fatalf(`ReadSampleSheet %d sample %d should be %v but is %v`, i, j, tt.want[j], *got[j])
+			}
+		}
+	}
+
+	for _, content := range []string{
+		"[Data]\nSample_ID,index\nS1,ACGT\n,CCGG\n",
+		"[Data]\nSample_ID,index\nS1,ACGT\nS2,\n",
+	} {
+		file := filepath.Join(dir, "sheet.csv")
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf(`unable to write %s: %v`, file, err)
+		}
+		if _, err := ReadSampleSheet(file); err == nil || !strings.Contains(err.Error(), `line 4`) {
+			t.Fatalf(`ReadSampleSheet should fail naming line 4 but error is %v`, err)
+		}
+	}
+}
+
+func TestNewDemultiplexer(t *testing.T) {
+	tests := []struct {
+		samples []*SampleBarcode
+		err     string
+	}{
+		{[]*SampleBarcode{{`S1`, `AAAAAAAA`, ``}, {`S2`, `AAAAAATT`, ``}}, `collide`},
+		{[]*SampleBarcode{{`S1`, `AAAAAAAA`, `CCCC`}, {`S2`, `AAAAAAAA`, `GGGG`}}, ``},
+		{[]*SampleBarcode{{`S1`, `AAAAAAAA`, ``}, {`S1`, `CCCCCCCC`, ``}}, `twice`},
+		{[]*SampleBarcode{{`S1`, `AAAAAAAA`, ``}, {`S2`, `CCCCCC`, ``}}, `lengths`},
+		{[]*SampleBarcode{{`Undetermined`, `AAAAAAAA`, ``}}, `invalid`},
+	}
+	for i, tt := range tests {
+		_, err := NewDemultiplexer(tt.samples, nil)
+		if tt.err == `` && err != nil {
+			t.Fatalf(`NewDemultiplexer %d failed: %v`, i, err)
+		}
+		if tt.err != `` && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Fatalf(`NewDemultiplexer %d should fail with %q but error is %v`, i, tt.err, err)
+		}
+	}
+}
+
+func TestDemultiplexer(t *testing.T) {
+	samples := []*SampleBarcode{{`S1`, `ACGTACGT`, `TTTTCCCC`}, {`S2`, `GGGGAAAA`, `CACACACA`}}
+	opts := NewDemuxOptions()
+	opts.OutDir = t.TempDir()
+	d, err := NewDemultiplexer(samples, opts)
+	if err != nil {
+		t.Fatalf(`NewDemultiplexer failed: %v`, err)
+	}
+
+	tests := []struct {
+		id     string
+		sample string
+	}{
+		{`A1:1:FC:1:1101:1:1 1:N:0:ACGTACGT+TTTTCCCC`, `S1`},
+		{`A1:1:FC:1:1101:1:2 1:N:0:ACGTACGA+TTTTCCCC`, `S1`},
+		{`A1:1:FC:1:1101:1:3 1:N:0:GGGGAAAA+CACACACA`, `S2`},
+		{`A1:1:FC:1:1101:1:4 1:N:0:GGGGAATT+CACACACA`, UndeterminedSample},
+		{`A1:1:FC:1:1101:1:5 1:N:0:GGGGAAAA+CACACANA`, `S2`},
+		{`read6`, UndeterminedSample},
+	}
+	for _, tt := range tests {
+		r1 := trimRec(`ACGT`, ``)
+		r1.Id = tt.id
+		r2 := trimRec(`TTGA`, ``)
+		r2.Id = tt.id
+		got, err := d.WriteByHeader(r1, r2)
+		if err != nil {
+			t.Fatalf(`WriteByHeader(%s) failed: %v`, tt.id, err)
+		}
+		if got != tt.sample {
+			t.Fatalf(`WriteByHeader(%s) should be %s but is %s`, tt.id, tt.sample, got)
+		}
+	}
+	if s := d.Match(`ACGTACGT`, `TTTTCCCC`); s == nil || s.Sample != `S1` {
+		t.Fatalf(`Match should find S1`)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf(`Close failed: %v`, err)
+	}
+
+	ff, err := OpenFastqFile(filepath.Join(opts.OutDir, `S1_R2.fastq.gz`))
+	if err != nil {
+		t.Fatalf(`OpenFastqFile failed: %v`, err)
+	}
+	defer ff.Close()
+	ids, err := readAllFastq(t, ff)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf(`S1_R2 should have 2 reads but has %d: %v`, len(ids), err)
+	}
+	if _, err := os.Stat(filepath.Join(opts.OutDir, `Undetermined_R1.fastq.gz`)); err != nil {
+		t.Fatalf(`Undetermined_R1.fastq.gz should exist: %v`, err)
+	}
+
+	want := "#sample\ti7\ti5\treads\tpercent\tperfect\n" +
+		"S1\tACGTACGT\tTTTTCCCC\t2\t33.33\t1\n" +
+		"S2\tGGGGAAAA\tCACACACA\t2\t33.33\t1\n" +
+		"Undetermined\t\t\t2\t33.33\t0\n"
+	if d.Summary() != want {
+		t.Fatalf("Summary should be\n%s but is\n%s", want, d.Summary())
+	}
+}
+
+func TestDemultiplexerUncompressed(t *testing.T) {
+	opts := NewDemuxOptions()
+	opts.OutDir = t.TempDir()
+	opts.Writer.Compression = NoCompression
+	d, err := NewDemultiplexer([]*SampleBarcode{{`S1`, `ACGT`, ``}}, opts)
+	if err != nil {
+		t.Fatalf(`NewDemultiplexer failed: %v`, err)
+	}
+	if _, err := d.Write(`ACGT`, ``, trimRec(`ACGT`, ``)); err != nil {
+		t.Fatalf(`Write failed: %v`, err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf(`Close failed: %v`, err)
+	}
+	b, err := os.ReadFile(filepath.Join(opts.OutDir, `S1_R1.fastq`))
+	if err != nil {
+		t.Fatalf(`S1_R1.fastq should exist: %v`, err)
+	}
+	if !strings.HasPrefix(string(b), `@`) {
+		t.Fatalf(`S1_R1.fastq should be uncompressed FASTQ`)
+	}
+}
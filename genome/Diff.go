@@ -0,0 +1,294 @@
+package genome
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grendeloz/ngs/region"
+)
+
+// DiffStatus describes how a sequence differs between two Genomes.
+type DiffStatus int
+
+const (
+	Identical DiffStatus = iota // same name and content
+	Changed                     // same name, different content
+	Renamed                     // same content, different name
+	Removed                     // only in the first Genome
+	Added                       // only in the second Genome
+)
+
+var diffStatusNames = map[DiffStatus]string{
+	Identical: `identical`,
+	Changed:   `changed`,
+	Renamed:   `renamed`,
+	Removed:   `removed`,
+	Added:     `added`,
+}
+
+func (d DiffStatus) String() string {
+	if n, ok := diffStatusNames[d]; ok {
+		return n
+	}
+	return `unknown`
+}
+
+// SequenceDiff is the comparison of one sequence between Genomes A and
+// B. NameA and NameB are empty for Added and Removed sequences
+// respectively. For Changed sequences of the same length, Regions are
+// the runs of differing bases, in A coordinates, and Substitutions is
+// the number of differing bases. Changed sequences of different
+// lengths are not aligned. Instead the common prefix and suffix are
+// trimmed and the rest is reported as one Region in A and the
+// matching RegionB in B, so an insertion or deletion gives its
+// position and both lengths. As in VCF, when one side of the
+// difference is empty both Regions are extended by a base so neither
+// is empty, unless that sequence is itself empty in which case its
+// Region is nil.
+type SequenceDiff struct {
+	Status        DiffStatus
+	NameA         string
+	NameB         string
+	LengthA       int
+	LengthB       int
+	MD5A          string
+	MD5B          string
+	Substitutions int
+	Regions       []*region.Region
+	RegionB       *region.Region
+}
+
+// String returns a one line description of the SequenceDiff.
+func (sd *SequenceDiff) String() string {
+	switch sd.Status {
+	case Identical:
+		return fmt.Sprintf("identical\t%s\t%d", sd.NameA, sd.LengthA)
+	case Renamed:
+		return fmt.Sprintf("renamed\t%s\t%s\t%d", sd.NameA, sd.NameB, sd.LengthA)
+	case Removed:
+		return fmt.Sprintf("removed\t%s\t%d", sd.NameA, sd.LengthA)
+	case Added:
+		return fmt.Sprintf("added\t%s\t%d", sd.NameB, sd.LengthB)
+	}
+	s := fmt.Sprintf("changed\t%s\t%d\t%d", sd.NameA, sd.LengthA, sd.LengthB)
+	if sd.LengthA == sd.LengthB {
+		var regs []string
+		for _, r := range sd.Regions {
+			regs = append(regs, r.String())
+		}
+		s += fmt.Sprintf("\t%d\t%s", sd.Substitutions, strings.Join(regs, ","))
+	} else {
+		ra, rb := `.`, `.`
+		if len(sd.Regions) > 0 {
+			ra = sd.Regions[0].String()
+		}
+		if sd.RegionB != nil {
+			rb = sd.RegionB.String()
+		}
+		s += fmt.Sprintf("\t%s\t%s", ra, rb)
+	}
+	return s
+}
+
+// GenomeDiff is the result of comparing two Genomes. Sequences holds
+// one SequenceDiff for each sequence: first those from A in A order,
+// then those only in B in B order.
+type GenomeDiff struct {
+	NameA     string
+	NameB     string
+	Sequences []*SequenceDiff
+}
+
+// IsIdentical returns true if every sequence is Identical.
+func (gd *GenomeDiff) IsIdentical() bool {
+	for _, sd := range gd.Sequences {
+		if sd.Status != Identical {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of sequences with the given status.
+func (gd *GenomeDiff) Count(status DiffStatus) int {
+	var n int
+	for _, sd := range gd.Sequences {
+		if sd.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// String returns a tab-separated report with one line per sequence.
+func (gd *GenomeDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s vs %s\n", gd.NameA, gd.NameB))
+	for _, sd := range gd.Sequences {
+		sb.WriteString(sd.String() + "\n")
+	}
+	return sb.String()
+}
+
+// Diff compares Genome g (A) with Genome o (B). Sequences are matched
+// first by name and then, for sequences whose name is only in one
+// Genome, by the MD5 of their content so a renamed sequence is
+// reported as Renamed rather than as Removed plus Added. Content is
+// compared case-insensitively (see FastaRec.MD5) so soft-masking
+// changes are ignored. Either Genome may be lazy, in which case its
+// sequences are loaded one at a time.
+func (g *Genome) Diff(o *Genome) (*GenomeDiff, error) {
+	gd := &GenomeDiff{NameA: g.Name, NameB: o.Name}
+
+	infoA, err := diffInfos(g)
+	if err != nil {
+		return nil, fmt.Errorf("genome.Genome.Diff: %w", err)
+	}
+	infoB, err := diffInfos(o)
+	if err != nil {
+		return nil, fmt.Errorf("genome.Genome.Diff: %w", err)
+	}
+
+	byNameA := make(map[string]*SequenceDiff)
+	for _, a := range infoA {
+		byNameA[a.NameA] = a
+	}
+	byNameB := make(map[string]*SequenceDiff)
+	// B sequences whose names are not in A, by MD5, for rename
+	// detection
+	md5B := make(map[string][]*SequenceDiff)
+	for _, b := range infoB {
+		byNameB[b.NameB] = b
+		if _, ok := byNameA[b.NameB]; !ok {
+			md5B[b.MD5B] = append(md5B[b.MD5B], b)
+		}
+	}
+	matchedB := make(map[string]bool)
+
+	for _, sd := range infoA {
+		if b, ok := byNameB[sd.NameA]; ok {
+			sd.NameB, sd.LengthB, sd.MD5B = b.NameB, b.LengthB, b.MD5B
+			if sd.MD5A == sd.MD5B {
+				sd.Status = Identical
+			} else {
+				sd.Status = Changed
+				ra, err := g.GetSequence(sd.NameA)
+				if err != nil {
+					return nil, fmt.Errorf("genome.Genome.Diff: %w", err)
+				}
+				rb, err := o.GetSequence(sd.NameB)
+				if err != nil {
+					return nil, fmt.Errorf("genome.Genome.Diff: %w", err)
+				}
+				if sd.LengthA == sd.LengthB {
+					sd.Substitutions, sd.Regions = substitutions(ra, rb)
+				} else {
+					regA, regB := differingSpan(ra, rb)
+					if regA != nil {
+						sd.Regions = []*region.Region{regA}
+					}
+					sd.RegionB = regB
+				}
+			}
+		} else if bs := md5B[sd.MD5A]; len(bs) > 0 {
+			b := bs[0]
+			md5B[sd.MD5A] = bs[1:]
+			matchedB[b.NameB] = true
+			sd.Status = Renamed
+			sd.NameB, sd.LengthB, sd.MD5B = b.NameB, b.LengthB, b.MD5B
+		} else {
+			sd.Status = Removed
+			sd.NameB, sd.LengthB, sd.MD5B = ``, 0, ``
+		}
+		gd.Sequences = append(gd.Sequences, sd)
+	}
+
+	for _, b := range infoB {
+		if _, ok := byNameA[b.NameB]; ok || matchedB[b.NameB] {
+			continue
+		}
+		b.Status = Added
+		b.NameA, b.LengthA, b.MD5A = ``, 0, ``
+		gd.Sequences = append(gd.Sequences, b)
+	}
+	return gd, nil
+}
+
+// diffInfos returns a SequenceDiff holding the name, length and MD5 of
+// each sequence in the Genome. Both the A and B fields are set so the
+// same function serves both Genomes.
+func diffInfos(g *Genome) ([]*SequenceDiff, error) {
+	var sds []*SequenceDiff
+	for _, n := range g.SequenceNames() {
+		r, err := g.GetSequence(n)
+		if err != nil {
+			return nil, err
+		}
+		md5 := r.MD5()
+		sds = append(sds, &SequenceDiff{NameA: n, NameB: n,
+			LengthA: r.Length(), LengthB: r.Length(), MD5A: md5, MD5B: md5})
+	}
+	return sds, nil
+}
+
+// substitutions compares two sequences of the same length base by base
+// (ignoring case) and returns the number of differing bases and the
+// runs of differing bases as Regions on a.
+func substitutions(a, b *FastaRec) (int, []*region.Region) {
+	var n int
+	var regs []*region.Region
+	start := -1
+	for i := 0; i <= len(a.Sequence); i++ {
+		if i < len(a.Sequence) && toUpper(a.Sequence[i]) != toUpper(b.Sequence[i]) {
+			n++
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			regs = append(regs, &region.Region{SeqId: a.Name, Start: start + 1, End: i})
+			start = -1
+		}
+	}
+	return n, regs
+}
+
+// differingSpan trims the longest common prefix and suffix (ignoring
+// case) of two sequences of different lengths and returns what is left
+// of each as a Region, extending both by a base if either would be
+// empty. A Region is nil if its sequence is empty.
+func differingSpan(a, b *FastaRec) (*region.Region, *region.Region) {
+	la, lb := len(a.Sequence), len(b.Sequence)
+	short := la
+	if lb < short {
+		short = lb
+	}
+	p := 0
+	for p < short && toUpper(a.Sequence[p]) == toUpper(b.Sequence[p]) {
+		p++
+	}
+	s := 0
+	for s < short-p && toUpper(a.Sequence[la-1-s]) == toUpper(b.Sequence[lb-1-s]) {
+		s++
+	}
+	// 0-based half-open spans
+	startA, endA := p, la-s
+	startB, endB := p, lb-s
+	if short > 0 && (startA == endA || startB == endB) {
+		if p > 0 {
+			startA--
+			startB--
+		} else {
+			endA++
+			endB++
+		}
+	}
+	span := func(name string, start, end int) *region.Region {
+		if start == end {
+			return nil
+		}
+		return &region.Region{SeqId: name, Start: start + 1, End: end}
+	}
+	return span(a.Name, startA, endA), span(b.Name, startB, endB)
+}
@@ -0,0 +1,95 @@
+package genome
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGenomeDiff(t *testing.T) {
+	ga := NewGenome(`A`)
+	gb := NewGenome(`B`)
+	add := func(g *Genome, name, seq string) {
+		r := NewFastaRec(`>` + name)
+		r.Sequence = seq
+		g.Sequences = append(g.Sequences, r)
+	}
+	add(ga, `chr1`, `ACGTACGTAC`)
+	add(ga, `chr2`, `AAAACCCCGG`)
+	add(ga, `chr3`, `TTTT`)
+	add(ga, `chrMT`, `GATCACAGGT`)
+	add(ga, `chrUn`, `NNNN`)
+	add(gb, `chr1`, `acgtacgtac`)
+	add(gb, `chr2`, `AAATCCCCGA`)
+	add(gb, `chr3`, `TTTTT`)
+	add(gb, `chrM`, `GATCACAGGT`)
+	add(gb, `chrY`, `CCCC`)
+
+	gd, err := ga.Diff(gb)
+	if err != nil {
+		t.Fatalf(`Diff failed: %v`, err)
+	}
+	e := []string{
+		"identical\tchr1\t10",
+		"changed\tchr2\t10\t10\t2\tchr2:4-4,chr2:10-10",
+		"changed\tchr3\t4\t5\tchr3:4-4\tchr3:4-5",
+		"renamed\tchrMT\tchrM\t10",
+		"removed\tchrUn\t4",
+		"added\tchrY\t4",
+	}
+	if len(gd.Sequences) != len(e) {
+		t.Fatalf(`Diff should have %d sequences but has %d`, len(e), len(gd.Sequences))
+	}
+	for i, sd := range gd.Sequences {
+		if sd.String() != e[i] {
+			t.Fatalf(`SequenceDiff %d should be %q but is %q`, i, e[i], sd.String())
+		}
+	}
+	if gd.IsIdentical() || gd.Count(Changed) != 2 || gd.Count(Added) != 1 {
+		t.Fatalf(`GenomeDiff counts incorrect`)
+	}
+
+	gd, err = ga.Diff(ga)
+	if err != nil {
+		t.Fatalf(`Diff failed: %v`, err)
+	}
+	if !gd.IsIdentical() {
+		t.Fatalf(`Genome should be identical to itself`)
+	}
+}
+
+func TestGenomeDiffIndel(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		regionA string
+		regionB string
+	}{
+		// Insertion of TT in B after base 4
+		{`ACGTACGTAC`, `ACGTTTACGTAC`, `chrT:4-4`, `chrT:4-6`},
+		// Deletion of CCCC from B after base 4
+		{`AAAACCCCGG`, `AAAAGG`, `chrT:4-8`, `chrT:4-4`},
+		// Insertion at the start
+		{`ACGT`, `GGACGT`, `chrT:1-1`, `chrT:1-3`},
+		// Substitution and insertion
+		{`AAAACCCCGG`, `AAAATTTTTGG`, `chrT:5-8`, `chrT:5-9`},
+		{`ACGT`, ``, `chrT:1-4`, `.`},
+	}
+	for _, tt := range tests {
+		ga := NewGenome(`A`)
+		ra := NewFastaRec(`>chrT`)
+		ra.Sequence = tt.a
+		ga.Sequences = append(ga.Sequences, ra)
+		gb := NewGenome(`B`)
+		rb := NewFastaRec(`>chrT`)
+		rb.Sequence = tt.b
+		gb.Sequences = append(gb.Sequences, rb)
+
+		gd, err := ga.Diff(gb)
+		if err != nil {
+			t.Fatalf(`Diff failed: %v`, err)
+		}
+		e := fmt.Sprintf("changed\tchrT\t%d\t%d\t%s\t%s", len(tt.a), len(tt.b), tt.regionA, tt.regionB)
+		if s := gd.Sequences[0].String(); s != e {
+			t.Fatalf(`Diff of %s and %s should be %q but is %q`, tt.a, tt.b, e, s)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package genome
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// This is a Go port of the symmetric DUST algorithm from:
+//
+//	Morgulis A, Gertz EM, Schaffer AA, Agarwala R. (2006) A fast and
+//	symmetric DUST implementation to mask low-complexity DNA sequences.
+//	J Comput Biol 13:1028-1040.
+//
+// It follows the structure of the sdust implementation by Heng Li that
+// is part of minimap2. Low-complexity regions are found by scoring the
+// triplets within a sliding window: a window of l triplets where
+// triplet t occurs c_t times scores sum(c_t*(c_t-1)/2)/(l-1) and any
+// "perfect" interval that scores above the threshold, and that no
+// sub-interval outscores, is masked. The scores are kept as integers
+// scaled by 10 to avoid floating point.
+
+const (
+	dustWordLen = 3
+	dustWords   = 1 << (2 * dustWordLen)
+	dustMask    = dustWords - 1
+)
+
+// Default window and threshold for Dust, the same as NCBI dustmasker.
+const (
+	DustWindow    = 64
+	DustThreshold = 20
+)
+
+type dustPerfect struct {
+	start, finish int
+	r, l          int
+}
+
+type duster struct {
+	window    int
+	threshold int
+
+	w      []int // triplets in the current window
+	l      int   // number of triplets in the suffix being scored
+	rw, rv int
+	cw, cv [dustWords]int
+
+	perfect []dustPerfect // sorted by start, largest first
+	res     [][2]int
+}
+
+func (d *duster) reset() {
+	d.w = d.w[:0]
+	d.l, d.rw, d.rv = 0, 0, 0
+	d.cw = [dustWords]int{}
+	d.cv = [dustWords]int{}
+}
+
+func (d *duster) shiftWindow(t int) {
+	if len(d.w) >= d.window-dustWordLen+1 {
+		s := d.w[0]
+		d.w = d.w[1:]
+		d.cw[s]--
+		d.rw -= d.cw[s]
+		if d.l > len(d.w) {
+			d.l--
+			d.cv[s]--
+			d.rv -= d.cv[s]
+		}
+	}
+	d.w = append(d.w, t)
+	d.l++
+	d.rw += d.cw[t]
+	d.cw[t]++
+	d.rv += d.cv[t]
+	d.cv[t]++
+	if d.cv[t]*10 > d.threshold*2 {
+		for {
+			s := d.w[len(d.w)-d.l]
+			d.cv[s]--
+			d.rv -= d.cv[s]
+			d.l--
+			if s == t {
+				break
+			}
+		}
+	}
+}
+
+func (d *duster) saveMasked(start int) {
+	n := len(d.perfect)
+	if n == 0 || d.perfect[n-1].start >= start {
+		return
+	}
+	p := d.perfect[n-1]
+	saved := false
+	if len(d.res) > 0 {
+		last := &d.res[len(d.res)-1]
+		if p.start <= last[1] {
+			saved = true
+			if p.finish > last[1] {
+				last[1] = p.finish
+			}
+		}
+	}
+	if !saved {
+		d.res = append(d.res, [2]int{p.start, p.finish})
+	}
+	i := n - 1
+	for i >= 0 && d.perfect[i].start < start {
+		i--
+	}
+	d.perfect = d.perfect[:i+1]
+}
+
+func (d *duster) findPerfect(start int) {
+	c := d.cv
+	r := d.rv
+	var maxR, maxL int
+	for i := len(d.w) - d.l - 1; i >= 0; i-- {
+		t := d.w[i]
+		r += c[t]
+		c[t]++
+		newR, newL := r, len(d.w)-i-1
+		if newR*10 <= d.threshold*newL {
+			continue
+		}
+		j := 0
+		for ; j < len(d.perfect) && d.perfect[j].start >= i+start; j++ {
+			p := d.perfect[j]
+			if maxR == 0 || p.r*maxL > maxR*p.l {
+				maxR, maxL = p.r, p.l
+			}
+		}
+		if maxR == 0 || newR*maxL >= maxR*newL {
+			maxR, maxL = newR, newL
+			p := dustPerfect{start: i + start,
+				finish: len(d.w) + dustWordLen - 1 + start,
+				r:      newR,
+				l:      newL}
+			d.perfect = append(d.perfect, dustPerfect{})
+			copy(d.perfect[j+1:], d.perfect[j:])
+			d.perfect[j] = p
+		}
+	}
+}
+
+// dust returns the 0-based half-open limits of the low-complexity
+// regions in seq. Any base other than A, C, G or T (in either case)
+// splits the sequence into independent pieces.
+func dust(seq string, window, threshold int) [][2]int {
+	d := &duster{window: window, threshold: threshold}
+	l, t := 0, 0
+	for i := 0; i <= len(seq); i++ {
+		b := 4
+		if i < len(seq) {
+			b = nt4(seq[i])
+		}
+		if b < 4 {
+			l++
+			t = (t<<2 | b) & dustMask
+			if l >= dustWordLen {
+				start := i + 1 - l
+				if l-window > 0 {
+					start += l - window
+				}
+				d.saveMasked(start)
+				d.shiftWindow(t)
+				if d.rw*10 > d.l*d.threshold {
+					d.findPerfect(start)
+				}
+			}
+			continue
+		}
+		start := i + 1 - l
+		if l-window+1 > 0 {
+			start += l - window + 1
+		}
+		for len(d.perfect) > 0 {
+			d.saveMasked(start)
+			start++
+		}
+		d.reset()
+		l, t = 0, 0
+	}
+	return d.res
+}
+
+func nt4(b byte) int {
+	switch b {
+	case 'A', 'a':
+		return 0
+	case 'C', 'c':
+		return 1
+	case 'G', 'g':
+		return 2
+	case 'T', 't':
+		return 3
+	}
+	return 4
+}
+
+// Dust finds low-complexity regions in the FastaRec with the symmetric
+// DUST algorithm and returns a gff3.Feature of Type
+// low_complexity_region for each. Window and threshold of 0 select the
+// defaults, DustWindow and DustThreshold. Lower thresholds mask more
+// sequence. The regions can be soft-masked with Genome.ApplyMask.
+func (r *FastaRec) Dust(window, threshold int) []*gff3.Feature {
+	if window <= 0 {
+		window = DustWindow
+	}
+	if threshold <= 0 {
+		threshold = DustThreshold
+	}
+	var feats []*gff3.Feature
+	for _, reg := range dust(r.Sequence, window, threshold) {
+		f := gff3.NewFeature()
+		f.SeqId = r.Name
+		f.Source = `grz-dust`
+		f.Type = `low_complexity_region`
+		f.Start = reg[0] + 1
+		f.End = reg[1]
+		f.Attributes[`Length`] = strconv.Itoa(reg[1] - reg[0])
+		feats = append(feats, f)
+	}
+	return feats
+}
+
+// Dust finds the low-complexity regions in every sequence of the
+// Genome and returns them as a sorted gff3.Features. See FastaRec.Dust.
+func (g *Genome) Dust(window, threshold int) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(r *FastaRec) error {
+		fs.AddFeatures(r.Dust(window, threshold)...)
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.Dust: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
@@ -0,0 +1,54 @@
+package genome
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// randomSequence returns a reproducible pseudo-random DNA sequence.
+func randomSequence(seed int64, length int) string {
+	rng := rand.New(rand.NewSource(seed))
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+func TestDust(t *testing.T) {
+	r := NewFastaRec(">chrT")
+	r.Sequence = randomSequence(1, 200) + strings.Repeat("CA", 40) + randomSequence(2, 200)
+
+	feats := r.Dust(0, 0)
+	if len(feats) != 1 {
+		t.Fatalf(`Dust should find 1 region but found %d`, len(feats))
+	}
+	// The region must cover the CA repeat (201-280) but may extend a
+	// few bases into the flanks where they happen to continue the
+	// repeat.
+	if feats[0].Start > 201 || feats[0].End < 280 ||
+		feats[0].Start < 190 || feats[0].End > 290 {
+		t.Fatalf(`Dust region should cover 201-280 but is %s`, feats[0].String())
+	}
+	if feats[0].Type != `low_complexity_region` {
+		t.Fatalf(`Dust Feature Type should be low_complexity_region but is %s`, feats[0].Type)
+	}
+
+	// A homopolymer either side of an N is found as two regions
+	r.Sequence = randomSequence(3, 100) + strings.Repeat("a", 30) + "N" +
+		strings.Repeat("t", 30) + randomSequence(4, 100)
+	feats = r.Dust(0, 0)
+	if len(feats) != 2 {
+		t.Fatalf(`Dust should find 2 regions but found %d`, len(feats))
+	}
+	if feats[0].End > 130 || feats[1].Start < 132 {
+		t.Fatalf(`Dust regions should not span the N: %s %s`, feats[0].String(), feats[1].String())
+	}
+
+	// Random sequence has no low-complexity regions
+	r.Sequence = randomSequence(5, 1000)
+	if feats = r.Dust(0, 0); len(feats) != 0 {
+		t.Fatalf(`Dust should find 0 regions in random sequence but found %d`, len(feats))
+	}
+}
@@ -0,0 +1,303 @@
+package genome
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FaiRecord is a single line from a samtools faidx index (.fai). Offset
+// is the byte offset of the first base of the sequence, LineBases is
+// the number of bases on each full line and LineWidth is the number of
+// bytes on each full line including the line ending.
+type FaiRecord struct {
+	Name      string
+	Length    int
+	Offset    int64
+	LineBases int
+	LineWidth int
+}
+
+// String returns the FaiRecord as a tab-separated .fai line.
+func (r *FaiRecord) String() string {
+	return strings.Join([]string{r.Name,
+		strconv.Itoa(r.Length),
+		strconv.FormatInt(r.Offset, 10),
+		strconv.Itoa(r.LineBases),
+		strconv.Itoa(r.LineWidth)}, "\t")
+}
+
+// offset returns the byte offset of the 0-based position pos.
+func (r *FaiRecord) offset(pos int) int64 {
+	return r.Offset + int64(pos/r.LineBases)*int64(r.LineWidth) + int64(pos%r.LineBases)
+}
+
+// Fai is the contents of a FASTA index. File is the .fai file if the
+// index was read from disk.
+type Fai struct {
+	File    string
+	Records []*FaiRecord
+}
+
+// NewFaiFromFile reads a .fai file.
+func NewFaiFromFile(file string) (*Fai, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fai := &Fai{File: file}
+	scanner := bufio.NewScanner(f)
+	lctr := 0
+	for scanner.Scan() {
+		lctr++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) < 5 {
+			return nil, fmt.Errorf("genome.NewFaiFromFile: %s line %d should have 5 columns but has %d", file, lctr, len(cols))
+		}
+		r := &FaiRecord{Name: cols[0]}
+		var ints [4]int64
+		for i := range ints {
+			ints[i], err = strconv.ParseInt(cols[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("genome.NewFaiFromFile: %s line %d: %w", file, lctr, err)
+			}
+		}
+		r.Length, r.Offset, r.LineBases, r.LineWidth = int(ints[0]), ints[1], int(ints[2]), int(ints[3])
+		if r.Length > 0 && (r.LineBases < 1 || r.LineWidth < r.LineBases) {
+			return nil, fmt.Errorf("genome.NewFaiFromFile: %s line %d has invalid line lengths", file, lctr)
+		}
+		fai.Records = append(fai.Records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return fai, nil
+}
+
+// NewFaiFromFasta builds the index for an uncompressed FASTA file. As
+// with samtools faidx, every line of a sequence except the last must
+// have the same length. The index is not written to disk - use Write.
+func NewFaiFromFasta(file string) (*Fai, error) {
+	if isGzipFile(file) {
+		return nil, fmt.Errorf("genome.NewFaiFromFasta: %s is compressed and cannot be indexed", file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fai := &Fai{}
+	reader := bufio.NewReader(f)
+	var (
+		offset    int64
+		rec       *FaiRecord
+		lctr      int
+		shortSeen bool // a line shorter than LineBases has been seen
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			lctr++
+			width := len(line)
+			bases := len(strings.TrimRight(line, "\r\n"))
+			switch {
+			case strings.HasPrefix(line, ">"):
+				name := NewFastaRec(strings.TrimRight(line, "\r\n")).Name
+				rec = &FaiRecord{Name: name, Offset: offset + int64(width)}
+				fai.Records = append(fai.Records, rec)
+				shortSeen = false
+			case strings.HasPrefix(line, ";"):
+				// FASTA comment lines are only allowed before the first
+				// record
+				if rec != nil {
+					return nil, fmt.Errorf("genome.NewFaiFromFasta: %s line %d: comment inside a sequence", file, lctr)
+				}
+			case rec == nil:
+				return nil, fmt.Errorf("genome.NewFaiFromFasta: %s line %d: sequence before the first header", file, lctr)
+			case bases == 0:
+				shortSeen = true
+			default:
+				if rec.LineBases == 0 {
+					rec.LineBases, rec.LineWidth = bases, width
+				} else if shortSeen || bases > rec.LineBases ||
+					(bases == rec.LineBases && width != rec.LineWidth) {
+					return nil, fmt.Errorf("genome.NewFaiFromFasta: %s line %d: %s has lines of different lengths", file, lctr, rec.Name)
+				}
+				if bases < rec.LineBases {
+					shortSeen = true
+				}
+				rec.Length += bases
+			}
+			offset += int64(width)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fai, nil
+}
+
+// Write writes the Fai to file.
+func (fai *Fai) Write(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	for _, r := range fai.Records {
+		if _, err := w.WriteString(r.String() + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IndexedFasta gives random access to the sequences in an uncompressed
+// FASTA file using a Fai. It is a SequenceSource.
+type IndexedFasta struct {
+	Filepath string
+	Fai      *Fai
+	file     *os.File
+	index    map[string]*FaiRecord
+}
+
+// OpenIndexedFasta opens an uncompressed FASTA file for random access.
+// If file.fai exists it is used, otherwise the index is built by
+// reading the whole file once.
+func OpenIndexedFasta(file string) (*IndexedFasta, error) {
+	var fai *Fai
+	var err error
+	if _, serr := os.Stat(file + `.fai`); serr == nil {
+		fai, err = NewFaiFromFile(file + `.fai`)
+	} else {
+		fai, err = NewFaiFromFasta(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("genome.OpenIndexedFasta: %w", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("genome.OpenIndexedFasta: %w", err)
+	}
+	ix := &IndexedFasta{Filepath: file, Fai: fai, file: f,
+		index: make(map[string]*FaiRecord)}
+	for _, r := range fai.Records {
+		ix.index[r.Name] = r
+	}
+	return ix, nil
+}
+
+// Names returns the sequence names in file order.
+func (ix *IndexedFasta) Names() []string {
+	var names []string
+	for _, r := range ix.Fai.Records {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// Length returns the length of the named sequence.
+func (ix *IndexedFasta) Length(name string) (int, error) {
+	r, ok := ix.index[name]
+	if !ok {
+		return 0, fmt.Errorf("genome.IndexedFasta.Length: sequence %s not found in %s", name, ix.Filepath)
+	}
+	return r.Length, nil
+}
+
+// Fetch returns the bases from start to end, a 0-based half-open
+// interval, of the named sequence.
+func (ix *IndexedFasta) Fetch(name string, start, end int) (string, error) {
+	r, ok := ix.index[name]
+	if !ok {
+		return ``, fmt.Errorf("genome.IndexedFasta.Fetch: sequence %s not found in %s", name, ix.Filepath)
+	}
+	if start < 0 || end > r.Length || start > end {
+		return ``, fmt.Errorf("genome.IndexedFasta.Fetch: %d-%d is outside %s (0-%d)", start, end, name, r.Length)
+	}
+	if start == end {
+		return ``, nil
+	}
+
+	from := r.offset(start)
+	to := r.offset(end-1) + 1
+	buf := make([]byte, to-from)
+	if _, err := ix.file.ReadAt(buf, from); err != nil {
+		return ``, fmt.Errorf("genome.IndexedFasta.Fetch: reading %s: %w", ix.Filepath, err)
+	}
+
+	seq := buf[:0]
+	for _, b := range buf {
+		if b != '\n' && b != '\r' {
+			seq = append(seq, b)
+		}
+	}
+	return string(seq), nil
+}
+
+// Header returns the header line, including the >, of the named
+// sequence. It is read back from the line before the sequence Offset.
+func (ix *IndexedFasta) Header(name string) (string, error) {
+	r, ok := ix.index[name]
+	if !ok {
+		return ``, fmt.Errorf("genome.IndexedFasta.Header: sequence %s not found in %s", name, ix.Filepath)
+	}
+	var line []byte
+	pos := r.Offset
+	for {
+		n := int64(256)
+		if pos < n {
+			n = pos
+		}
+		buf := make([]byte, n)
+		if _, err := ix.file.ReadAt(buf, pos-n); err != nil {
+			return ``, fmt.Errorf("genome.IndexedFasta.Header: reading %s: %w", ix.Filepath, err)
+		}
+		pos -= n
+		line = append(buf, line...)
+		t := bytes.TrimRight(line, "\r\n")
+		if i := bytes.LastIndexByte(t, '\n'); i >= 0 {
+			line = t[i+1:]
+			break
+		}
+		if pos == 0 {
+			line = t
+			break
+		}
+	}
+	if len(line) == 0 || line[0] != '>' {
+		return ``, fmt.Errorf("genome.IndexedFasta.Header: no header line before %s in %s", name, ix.Filepath)
+	}
+	return string(line), nil
+}
+
+// Close closes the FASTA file.
+func (ix *IndexedFasta) Close() error {
+	return ix.file.Close()
+}
+
+// isGzipFile returns true if file has a .gz extension.
+func isGzipFile(file string) bool {
+	found, _ := regexp.MatchString(`\.[gG][zZ]$`, file)
+	return found
+}
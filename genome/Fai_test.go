@@ -0,0 +1,126 @@
+package genome
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testFasta is a small FASTA with 10 base lines, soft-masking and Ns.
+var testFasta = ">chr1 first\nACGTACGTAC\nGTacgtNNNN\nACG\n>chr2\nTTTTTGGGGG\nCCCCCAAAAA\n>empty\n>chr3 | third\r\nACGT\r\n"
+
+func writeTestFasta(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "test.fa")
+	if err := os.WriteFile(file, []byte(testFasta), 0644); err != nil {
+		t.Fatalf(`unable to write %s: %v`, file, err)
+	}
+	return file
+}
+
+func TestNewFaiFromFasta(t *testing.T) {
+	file := writeTestFasta(t)
+	fai, err := NewFaiFromFasta(file)
+	if err != nil {
+		t.Fatalf(`NewFaiFromFasta failed: %v`, err)
+	}
+	e := []string{
+		"chr1\t23\t12\t10\t11",
+		"chr2\t20\t44\t10\t11",
+		"empty\t0\t73\t0\t0",
+		"chr3\t4\t88\t4\t6",
+	}
+	if len(fai.Records) != len(e) {
+		t.Fatalf(`Fai should have %d records but has %d`, len(e), len(fai.Records))
+	}
+	for i, r := range fai.Records {
+		if r.String() != e[i] {
+			t.Fatalf(`Fai record %d should be %q but is %q`, i, e[i], r.String())
+		}
+	}
+
+	// Round trip through a .fai file
+	if err := fai.Write(file + `.fai`); err != nil {
+		t.Fatalf(`Fai.Write failed: %v`, err)
+	}
+	fai2, err := NewFaiFromFile(file + `.fai`)
+	if err != nil {
+		t.Fatalf(`NewFaiFromFile failed: %v`, err)
+	}
+	for i, r := range fai2.Records {
+		if r.String() != e[i] {
+			t.Fatalf(`read Fai record %d should be %q but is %q`, i, e[i], r.String())
+		}
+	}
+
+	// Inconsistent line lengths cannot be indexed
+	bad := filepath.Join(t.TempDir(), "bad.fa")
+	if err := os.WriteFile(bad, []byte(">chr1\nACGT\nAC\nACGT\n"), 0644); err != nil {
+		t.Fatalf(`unable to write %s: %v`, bad, err)
+	}
+	if _, err := NewFaiFromFasta(bad); err == nil {
+		t.Fatalf(`NewFaiFromFasta should fail for inconsistent line lengths`)
+	}
+}
+
+func TestIndexedFastaFetch(t *testing.T) {
+	ix, err := OpenIndexedFasta(writeTestFasta(t))
+	if err != nil {
+		t.Fatalf(`OpenIndexedFasta failed: %v`, err)
+	}
+	defer ix.Close()
+
+	tests := []struct {
+		name       string
+		start, end int
+		e          string
+	}{
+		{`chr1`, 0, 23, `ACGTACGTACGTacgtNNNNACG`},
+		{`chr1`, 8, 12, `ACGT`},
+		{`chr1`, 20, 23, `ACG`},
+		{`chr2`, 9, 11, `GC`},
+		{`chr3`, 1, 4, `CGT`},
+		{`empty`, 0, 0, ``},
+	}
+	for _, tt := range tests {
+		g, err := ix.Fetch(tt.name, tt.start, tt.end)
+		if err != nil {
+			t.Fatalf(`Fetch(%s,%d,%d) failed: %v`, tt.name, tt.start, tt.end, err)
+		}
+		if g != tt.e {
+			t.Fatalf(`Fetch(%s,%d,%d) should be %s but is %s`, tt.name, tt.start, tt.end, tt.e, g)
+		}
+	}
+
+	if _, err := ix.Fetch(`chr1`, 20, 24); err == nil {
+		t.Fatalf(`Fetch beyond the end of a sequence should fail`)
+	}
+	if _, err := ix.Fetch(`chrZ`, 0, 1); err == nil {
+		t.Fatalf(`Fetch of an unknown sequence should fail`)
+	}
+}
+
+func TestIndexedFastaHeader(t *testing.T) {
+	ix, err := OpenIndexedFasta(writeTestFasta(t))
+	if err != nil {
+		t.Fatalf(`OpenIndexedFasta failed: %v`, err)
+	}
+	defer ix.Close()
+
+	for name, e := range map[string]string{
+		`chr1`:  `>chr1 first`,
+		`chr2`:  `>chr2`,
+		`empty`: `>empty`,
+		`chr3`:  `>chr3 | third`,
+	} {
+		h, err := ix.Header(name)
+		if err != nil {
+			t.Fatalf(`Header(%s) failed: %v`, name, err)
+		}
+		if h != e {
+			t.Fatalf(`Header(%s) should be %q but is %q`, name, e, h)
+		}
+	}
+	if _, err := ix.Header(`chrZ`); err == nil {
+		t.Fatalf(`Header(chrZ) should fail`)
+	}
+}
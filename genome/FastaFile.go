@@ -7,6 +7,8 @@ import (
 	"os"
 	"regexp"
 	"strings"
+
+	"github.com/grendeloz/ngs/selector"
 )
 
 // Pattern for header (comment) and Id lines
@@ -22,6 +24,8 @@ type FastaFile struct {
 	md5       string
 	nextRecId string
 	EOF       bool
+	selectors []*recSelector
+	renames   map[string]string
 }
 
 // OpenFastaFile opens a FASTA file and prepares it for reading.
@@ -77,16 +81,52 @@ func OpenFastaFile(file string) (*FastaFile, error) {
 	return fasta, nil
 }
 
+// AddSelectors adds selectors that are applied to every record read
+// by Next so records that are dropped by any selector are skipped. See
+// Genome.ApplySelectors for the selector subjects and operations.
+func (f *FastaFile) AddSelectors(sels ...*selector.Selector) error {
+	rss, err := newRecSelectors(sels)
+	if err != nil {
+		return fmt.Errorf("genome.FastaFile.AddSelectors: %w", err)
+	}
+	f.selectors = append(f.selectors, rss...)
+	return nil
+}
+
+// SetRenames sets a map of old to new names that is applied to every
+// record returned by Next. Selectors are applied before renaming so
+// they see the original names.
+func (f *FastaFile) SetRenames(names map[string]string) {
+	f.renames = names
+}
+
 // Next returns the next record from the FASTA file. If there are no
-// more records, it returns nil.
+// more records, it returns nil. Records dropped by any selectors are
+// skipped and renames are applied.
 func (f *FastaFile) Next() (*FastaRec, error) {
+	for {
+		rec, err := f.next()
+		if err != nil || rec == nil {
+			return rec, err
+		}
+		if !retains(f.selectors, rec) {
+			continue
+		}
+		if n, ok := f.renames[rec.Name]; ok {
+			rec.Rename(n)
+		}
+		f.recCtr++
+		return rec, nil
+	}
+}
+
+func (f *FastaFile) next() (*FastaRec, error) {
 	if f.EOF {
 		return nil, nil
 	}
 
 	thisRec := NewFastaRec(f.nextRecId)
 	thisRec.FastaFile = f
-	f.recCtr++
 	var seq strings.Builder
 
 	for f.scanner.Scan() {
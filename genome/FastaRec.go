@@ -57,3 +57,10 @@ func NewFastaRec(header string) *FastaRec {
 func (r *FastaRec) Length() int {
 	return len(r.Sequence)
 }
+
+// MD5 returns the MD5 hash of the uppercased sequence, the same digest
+// as the M5 tag of a SAM @SQ header line. Because the sequence is
+// uppercased, soft-masking does not change the digest.
+func (r *FastaRec) MD5() string {
+	return Md5String(strings.ToUpper(r.Sequence))
+}
@@ -12,19 +12,59 @@ import (
 // Pattern for header lines
 var fqHeaderRex *regexp.Regexp = regexp.MustCompile(`^#(.*)$`)
 
+// Long reads can have very long lines so the scanner buffer is allowed
+// to grow well beyond the bufio default of 64KB.
+const fqMaxLineLength = 256 * 1024 * 1024
+
+// FastqMode controls what FastqFile.Next does with a malformed record.
+type FastqMode int
+
+const (
+	// FastqStrict returns an error for the first malformed record.
+	FastqStrict FastqMode = iota
+	// FastqSkip skips malformed records and counts them.
+	FastqSkip
+	// FastqRepair repairs the records that can be repaired (see
+	// FastqFile.Next) and skips the rest.
+	FastqRepair
+)
+
+// FastqError is returned by FastqFile.Next for a malformed record.
+// Record is the 1-based number of the record in the file and Line is
+// the 1-based line number where the problem was found.
+type FastqError struct {
+	File   string
+	Record int
+	Line   int
+	Msg    string
+}
+
+func (e *FastqError) Error() string {
+	return fmt.Sprintf("%s line %d (record %d): %s", e.File, e.Line, e.Record, e.Msg)
+}
+
 // FastqFile
 type FastqFile struct {
-	Filepath  string
-	Headers   []string
-	scanner   *bufio.Scanner // used in Next()
-	recCtr    int
-	md5       string
-	nextRecId string
-	EOF       bool
+	Filepath string
+	Headers  []string
+	Mode     FastqMode
+	scanner  *bufio.Scanner // used in Next()
+	recCtr   int            // records returned
+	recNum   int            // records started, including skipped
+	lineCtr  int
+	pending  []string // lines read but not yet used, last is next
+	skipped  int
+	repaired int
+	md5      string
+	EOF      bool
+	file     *os.File
+	gz       *gzip.Reader
 }
 
 // OpenFastqFile opens a FASTQ file and prepares it for reading.
 // It will handle gzipped files as long as they have a .gz extension.
+// The file is read in FastqStrict mode unless Mode is changed before
+// the first call to Next. Call Close when finished with the file.
 func OpenFastqFile(file string) (*FastqFile, error) {
 	// As a side effect of reading the FASTQ
 	fastq := &FastqFile{Filepath: file}
@@ -36,21 +76,22 @@ func OpenFastqFile(file string) (*FastqFile, error) {
 	if err != nil {
 		return fastq, err
 	}
-
-	// We need to define this before we handle gzip
-	//var scanner *bufio.Scanner
+	fastq.file = f
 
 	// Based on file extension, handle gzip files
 	found, err := regexp.MatchString(`\.[gG][zZ]$`, file)
 	if err != nil {
+		fastq.Close()
 		return fastq, fmt.Errorf("error matching gzip file pattern: %v", err)
 	}
 	if found {
 		// For gzip files, put a gzip.Reader into the chain
 		reader, err := gzip.NewReader(f)
 		if err != nil {
+			fastq.Close()
 			return fastq, fmt.Errorf("unable to open gzip file %v: %w", file, err)
 		}
+		fastq.gz = reader
 		fastq.scanner = bufio.NewScanner(reader)
 	} else {
 		// For non gzip files, go straight to bufio.Reader
@@ -59,49 +100,193 @@ func OpenFastqFile(file string) (*FastqFile, error) {
 
 	// Unnecessary but explicit
 	fastq.scanner.Split(bufio.ScanLines)
+	fastq.scanner.Buffer(make([]byte, 0, 64*1024), fqMaxLineLength)
 
 	// Read header
-	for fastq.scanner.Scan() {
-		line := strings.TrimSuffix(fastq.scanner.Text(), "\n")
+	for {
+		line, ok := fastq.readLine()
+		if !ok {
+			break
+		}
 		if fqHeaderRex.MatchString(line) {
 			fastq.Headers = append(fastq.Headers, line)
 		} else {
 			// Found Id line of the first record so save and return
-			fastq.nextRecId = line
-			return fastq, nil
+			fastq.unreadLine(line)
+			break
 		}
 	}
+	if err := fastq.scanner.Err(); err != nil {
+		fastq.Close()
+		return fastq, fmt.Errorf("error reading %s: %w", file, err)
+	}
 
 	return fastq, nil
 }
 
+// Close closes the FASTQ file. It is safe to call Close more than
+// once.
+func (f *FastqFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	if f.gz != nil {
+		f.gz.Close()
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// readLine returns the next line, with any \r removed, or false at the
+// end of the file.
+func (f *FastqFile) readLine() (string, bool) {
+	if n := len(f.pending); n > 0 {
+		line := f.pending[n-1]
+		f.pending = f.pending[:n-1]
+		f.lineCtr++
+		return line, true
+	}
+	if !f.scanner.Scan() {
+		return ``, false
+	}
+	f.lineCtr++
+	return strings.TrimSuffix(f.scanner.Text(), "\r"), true
+}
+
+// unreadLine pushes lines back so the next readLine returns the first
+// of them.
+func (f *FastqFile) unreadLine(lines ...string) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		f.pending = append(f.pending, lines[i])
+		f.lineCtr--
+	}
+}
+
 // Next returns the next record from the FASTQ file. If there are no
-// more records, it returns nil.
+// more records, it returns nil. Blank lines between records are
+// ignored. A record is malformed if the Id line does not start with
+// @, the third line does not start with + or has text after the +
+// that is not the Id, the file ends part way through the record, or
+// FastqRec.CheckValid fails.
+//
+// In FastqStrict mode the first malformed record is returned as a
+// *FastqError. In FastqSkip mode malformed records are skipped and
+// counted, and reading resumes at the next line after the bad record's
+// Id line that starts with @. Because a quality line can also start
+// with @, a badly damaged file may give a skipped count that is higher
+// than the number of real records lost. FastqRepair mode is like
+// FastqSkip except that a record whose only problems are a + line that
+// does not match the Id, quality characters outside the Sanger range
+// (replaced with !) or Bases and Qualities of different lengths (both
+// are trimmed to the shorter length) is repaired, counted and
+// returned.
 func (f *FastqFile) Next() (*FastqRec, error) {
-	if f.EOF {
-		return nil, nil
+	for {
+		if f.EOF {
+			return nil, nil
+		}
+
+		lines, rec, ferr := f.parseRecord()
+		if rec == nil && ferr == nil {
+			f.EOF = true
+			if err := f.scanner.Err(); err != nil {
+				return nil, fmt.Errorf("genome.FastqFile.Next: %s line %d: %w", f.Filepath, f.lineCtr, err)
+			}
+			return nil, nil
+		}
+		if ferr == nil {
+			f.recCtr++
+			return rec, nil
+		}
+
+		if f.Mode == FastqRepair && rec != nil {
+			if rec.repair() {
+				f.repaired++
+				f.recCtr++
+				return rec, nil
+			}
+		}
+		if f.Mode == FastqStrict {
+			return nil, fmt.Errorf("genome.FastqFile.Next: %w", ferr)
+		}
+
+		// Skip the record and resynchronise on the line after its Id
+		f.skipped++
+		if len(lines) > 1 {
+			f.unreadLine(lines[1:]...)
+		}
+		f.resync()
+	}
+}
+
+// parseRecord reads the lines of the next record. It returns the lines
+// it read, the record if all 4 lines were present (even if it is not
+// valid) and a *FastqError if the record is malformed. At the end of
+// the file it returns nil for both the record and the error.
+func (f *FastqFile) parseRecord() ([]string, *FastqRec, *FastqError) {
+	var line string
+	var ok bool
+	// Skip blank lines between records
+	for {
+		if line, ok = f.readLine(); !ok {
+			return nil, nil, nil
+		}
+		if strings.TrimSpace(line) != `` {
+			break
+		}
 	}
 
-	thisRec := NewFastqRec()
-	f.recCtr++
+	f.recNum++
+	lines := []string{line}
+	idLine := f.lineCtr
+	// fail reports a problem with line i (0-3) of the record
+	fail := func(i int, msg string, args ...interface{}) *FastqError {
+		return &FastqError{File: f.Filepath, Record: f.recNum, Line: idLine + i,
+			Msg: fmt.Sprintf(msg, args...)}
+	}
 
-	// First record special case - we already read the first line
-	if f.nextRecId != "" {
-		thisRec.Id = f.nextRecId
-		f.nextRecId = ""
-	} else {
-		f.scanner.Scan()
-		thisRec.Id = f.scanner.Text()
+	if !strings.HasPrefix(line, `@`) {
+		return lines, nil, fail(0, "Id line should start with @ but starts with %q", line[:1])
+	}
+	for len(lines) < 4 {
+		l, ok := f.readLine()
+		if !ok {
+			return lines, nil, fail(len(lines)-1, "file ends part way through a record")
+		}
+		lines = append(lines, l)
 	}
 
-	// Read the next 3 lines
-	f.scanner.Scan()
-	thisRec.Bases = []byte(f.scanner.Text())
-	f.scanner.Scan()
-	f.scanner.Scan()
-	thisRec.Qualities = []byte(f.scanner.Text())
+	rec := NewFastqRec()
+	rec.Id = lines[0][1:]
+	rec.Bases = []byte(lines[1])
+	rec.Qualities = []byte(lines[3])
 
-	return thisRec, nil
+	if !strings.HasPrefix(lines[2], `+`) {
+		return lines, nil, fail(2, "third line should start with + but is %q", lines[2])
+	}
+	if plus := lines[2][1:]; plus != `` && plus != rec.Id {
+		return lines, rec, fail(2, "+ line %q does not match Id %q", plus, rec.Id)
+	}
+	if err := rec.CheckValid(); err != nil {
+		return lines, rec, fail(3, "%v", err)
+	}
+	return lines, rec, nil
+}
+
+// resync discards lines until the next line that starts with @ which
+// is left to be read as the start of the next record.
+func (f *FastqFile) resync() {
+	for {
+		line, ok := f.readLine()
+		if !ok {
+			return
+		}
+		if strings.HasPrefix(line, `@`) {
+			f.unreadLine(line)
+			return
+		}
+	}
 }
 
 // RecordCount returns the number of records returned with Next().
@@ -109,6 +294,23 @@ func (f *FastqFile) RecordCount() int {
 	return f.recCtr
 }
 
+// SkippedCount returns the number of malformed records that Next has
+// skipped.
+func (f *FastqFile) SkippedCount() int {
+	return f.skipped
+}
+
+// RepairedCount returns the number of malformed records that Next has
+// repaired and returned.
+func (f *FastqFile) RepairedCount() int {
+	return f.repaired
+}
+
+// LineNumber returns the number of lines read so far.
+func (f *FastqFile) LineNumber() int {
+	return f.lineCtr
+}
+
 // MD5 will return the MD5 string for the file and will calculate it on
 // the first call, which is therefore slow. Subsequent calls return the
 // already-calculated value.
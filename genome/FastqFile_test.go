@@ -1,6 +1,9 @@
 package genome
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -11,15 +14,16 @@ func TestOpenFastqFile(t *testing.T) {
 		Qual string
 	}
 
-	// Records that are found in testdata/test1.fq
+	// Records that are found in testdata/test1.fq. The @ that starts
+	// the Id line is not part of the Id.
 	tests := []test{
-		{"@read1",
+		{"read1",
 			"ACGTCCAGCCACGTCCAGCCGACTCGGCGA",
 			"ABCDEFGHIJLKMNOPQRSTUVWXYZ1234"},
-		{"@read2",
+		{"read2",
 			"CGTCCAGCCACGTCCAGCCGACTCGGCGAA",
 			"BCDEFGHIJLKMNOPQRSTUVWXYZ12345"},
-		{"@read3",
+		{"read3",
 			"GTCCAGCCACGTCCAGCCGACTCGGCGAAC",
 			"CDEFGHIJLKMNOPQRSTUVWXYZ123456"},
 	}
@@ -31,6 +35,7 @@ func TestOpenFastqFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf(`OpenFastqFile on %s failed: %v`, file, err)
 	}
+	defer ff.Close()
 
 	// Check header
 	e1 := 1
@@ -69,4 +74,116 @@ func TestOpenFastqFile(t *testing.T) {
 				i, tst.Qual, qual)
 		}
 	}
+
+	// End of file is nil, and stays nil
+	for i := 0; i < 2; i++ {
+		rec, err := ff.Next()
+		if rec != nil || err != nil {
+			t.Fatalf(`Next() at end of file should return nil, nil`)
+		}
+	}
+	e3 := 3
+	g3 := ff.RecordCount()
+	if e3 != g3 {
+		t.Fatalf(`RecordCount should be %d but is %d`, e3, g3)
+	}
+}
+
+// badFastq has (in order) a good record, a record with too few
+// qualities, a record whose + line does not match, a record without a
+// + line, a record with a quality that starts with @ and a truncated
+// record.
+var badFastq = "@r1\nACGT\n+\nIIII\n" +
+	"@r2\nACGT\n+\nIII\n" +
+	"@r3\nACGT\n+r4\nIIII\n" +
+	"@r4\nACGT\nIIII\n" +
+	"@r5\nACGT\n+\n@III\n" +
+	"\n" +
+	"@r6\nACGT\n"
+
+func writeFastq(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "test.fq")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf(`unable to write %s: %v`, file, err)
+	}
+	return file
+}
+
+func readAllFastq(t *testing.T, ff *FastqFile) ([]string, error) {
+	var ids []string
+	for {
+		rec, err := ff.Next()
+		if err != nil {
+			return ids, err
+		}
+		if rec == nil {
+			return ids, nil
+		}
+		ids = append(ids, rec.Id)
+	}
+}
+
+func TestFastqFileStrict(t *testing.T) {
+	ff, err := OpenFastqFile(writeFastq(t, badFastq))
+	if err != nil {
+		t.Fatalf(`OpenFastqFile failed: %v`, err)
+	}
+	defer ff.Close()
+	ids, err := readAllFastq(t, ff)
+	if len(ids) != 1 || ids[0] != `r1` {
+		t.Fatalf(`strict mode should return r1 only but returned %v`, ids)
+	}
+	var ferr *FastqError
+	if !errors.As(err, &ferr) {
+		t.Fatalf(`strict mode should return a *FastqError but returned %v`, err)
+	}
+	if ferr.Record != 2 || ferr.Line != 8 {
+		t.Fatalf(`error should be at record 2 line 8 but is at record %d line %d`, ferr.Record, ferr.Line)
+	}
+}
+
+func TestFastqFileSkip(t *testing.T) {
+	ff, err := OpenFastqFile(writeFastq(t, badFastq))
+	if err != nil {
+		t.Fatalf(`OpenFastqFile failed: %v`, err)
+	}
+	defer ff.Close()
+	ff.Mode = FastqSkip
+	ids, err := readAllFastq(t, ff)
+	if err != nil {
+		t.Fatalf(`skip mode should not fail: %v`, err)
+	}
+	e := []string{`r1`, `r5`}
+	if len(ids) != len(e) || ids[0] != e[0] || ids[1] != e[1] {
+		t.Fatalf(`skip mode should return %v but returned %v`, e, ids)
+	}
+	if ff.SkippedCount() != 4 || ff.RepairedCount() != 0 {
+		t.Fatalf(`skip mode should skip 4 but skipped %d`, ff.SkippedCount())
+	}
+}
+
+func TestFastqFileRepair(t *testing.T) {
+	ff, err := OpenFastqFile(writeFastq(t, badFastq))
+	if err != nil {
+		t.Fatalf(`OpenFastqFile failed: %v`, err)
+	}
+	defer ff.Close()
+	ff.Mode = FastqRepair
+	ids, err := readAllFastq(t, ff)
+	if err != nil {
+		t.Fatalf(`repair mode should not fail: %v`, err)
+	}
+	e := []string{`r1`, `r2`, `r3`, `r5`}
+	if len(ids) != len(e) {
+		t.Fatalf(`repair mode should return %v but returned %v`, e, ids)
+	}
+	for i := range e {
+		if ids[i] != e[i] {
+			t.Fatalf(`repair mode should return %v but returned %v`, e, ids)
+		}
+	}
+	if ff.SkippedCount() != 2 || ff.RepairedCount() != 2 {
+		t.Fatalf(`repair mode should repair 2 and skip 2 but repaired %d and skipped %d`,
+			ff.RepairedCount(), ff.SkippedCount())
+	}
 }
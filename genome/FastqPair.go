@@ -0,0 +1,115 @@
+package genome
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FastqPairReader reads paired-end FASTQ, either from a pair of R1/R2
+// files or from a single interleaved file where each R1 record is
+// followed by its R2 record. It checks that the two reads of each pair
+// have the same name (see FastqPairName).
+type FastqPairReader struct {
+	R1      *FastqFile
+	R2      *FastqFile // same as R1 for an interleaved file
+	pairCtr int
+}
+
+// OpenFastqPair opens a pair of FASTQ files, R1 and R2, for reading in
+// lockstep. As with OpenFastqFile, gzipped files must have a .gz
+// extension. Call Close when finished with the files.
+func OpenFastqPair(file1, file2 string) (*FastqPairReader, error) {
+	r1, err := OpenFastqFile(file1)
+	if err != nil {
+		return nil, fmt.Errorf("genome.OpenFastqPair: %w", err)
+	}
+	r2, err := OpenFastqFile(file2)
+	if err != nil {
+		r1.Close()
+		return nil, fmt.Errorf("genome.OpenFastqPair: %w", err)
+	}
+	return &FastqPairReader{R1: r1, R2: r2}, nil
+}
+
+// OpenInterleavedFastq opens an interleaved paired-end FASTQ file.
+func OpenInterleavedFastq(file string) (*FastqPairReader, error) {
+	r, err := OpenFastqFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("genome.OpenInterleavedFastq: %w", err)
+	}
+	return &FastqPairReader{R1: r, R2: r}, nil
+}
+
+// Close closes the R1 and R2 files.
+func (p *FastqPairReader) Close() error {
+	err := p.R1.Close()
+	if err2 := p.R2.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return fmt.Errorf("genome.FastqPairReader.Close: %w", err)
+	}
+	return nil
+}
+
+// IsInterleaved returns true if the pairs are read from a single file.
+func (p *FastqPairReader) IsInterleaved() bool {
+	return p.R1 == p.R2
+}
+
+// Next returns the next pair of reads or two nils when there are no
+// more pairs. It is an error for the files to have different numbers
+// of records, for an interleaved file to have an odd number of records
+// or for the reads of a pair to have different names. Setting Mode on
+// R1 or R2 to skip malformed records is allowed but a skipped record
+// will put the reads out of step, which is then reported as an error.
+func (p *FastqPairReader) Next() (*FastqRec, *FastqRec, error) {
+	r1, err := p.R1.Next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("genome.FastqPairReader.Next: %w", err)
+	}
+	r2, err := p.R2.Next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("genome.FastqPairReader.Next: %w", err)
+	}
+
+	switch {
+	case r1 == nil && r2 == nil:
+		return nil, nil, nil
+	case r2 == nil && p.IsInterleaved():
+		return nil, nil, fmt.Errorf("genome.FastqPairReader.Next: %s ends with unpaired read %s",
+			p.R1.Filepath, r1.Id)
+	case r2 == nil:
+		return nil, nil, fmt.Errorf("genome.FastqPairReader.Next: %s has more records than %s (%d)",
+			p.R1.Filepath, p.R2.Filepath, p.R2.RecordCount())
+	case r1 == nil:
+		return nil, nil, fmt.Errorf("genome.FastqPairReader.Next: %s has more records than %s (%d)",
+			p.R2.Filepath, p.R1.Filepath, p.R1.RecordCount())
+	}
+
+	if n1, n2 := FastqPairName(r1.Id), FastqPairName(r2.Id); n1 != n2 {
+		return nil, nil, fmt.Errorf("genome.FastqPairReader.Next: reads out of sync at pair %d: %s does not match %s",
+			p.pairCtr+1, r1.Id, r2.Id)
+	}
+	p.pairCtr++
+	return r1, r2, nil
+}
+
+// PairCount returns the number of pairs returned by Next.
+func (p *FastqPairReader) PairCount() int {
+	return p.pairCtr
+}
+
+// FastqPairName returns the part of a read Id that is the same for both
+// reads of a pair. The comment after the first space, for example a
+// Casava 1.8 comment like 1:N:0:ACGT, is removed as is a trailing /1 or
+// /2 from older Illumina pipelines.
+func FastqPairName(id string) string {
+	if i := strings.IndexAny(id, " \t"); i >= 0 {
+		id = id[:i]
+	}
+	if strings.HasSuffix(id, `/1`) || strings.HasSuffix(id, `/2`) {
+		id = id[:len(id)-2]
+	}
+	return id
+}
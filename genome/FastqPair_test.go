@@ -0,0 +1,120 @@
+package genome
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFastqPairName(t *testing.T) {
+	tests := []struct {
+		id   string
+		name string
+	}{
+		{`r1`, `r1`},
+		{`r1/1`, `r1`},
+		{`r1/2`, `r1`},
+		{`r1/3`, `r1/3`},
+		{`M00123:45:000-AB:1:1101:15589:1331 1:N:0:ACGT`, `M00123:45:000-AB:1:1101:15589:1331`},
+		{`r1/1 extra words`, `r1`},
+		{"r1\tcomment", `r1`},
+	}
+	for _, tt := range tests {
+		if got := FastqPairName(tt.id); got != tt.name {
+			t.Fatalf(`FastqPairName(%q) should be %q but is %q`, tt.id, tt.name, got)
+		}
+	}
+}
+
+func fastqRecords(ids ...string) string {
+	var sb strings.Builder
+	for _, id := range ids {
+		sb.WriteString("@" + id + "\nACGT\n+\nIIII\n")
+	}
+	return sb.String()
+}
+
+func TestFastqPairReader(t *testing.T) {
+	tests := []struct {
+		name string
+		r1   []string
+		r2   []string // nil for interleaved
+		want int
+		err  string
+	}{
+		{`casava`, []string{`a 1:N:0:AC`, `b 1:N:0:AC`}, []string{`a 2:N:0:AC`, `b 2:N:0:AC`}, 2, ``},
+		{`slash`, []string{`a/1`, `b/1`}, []string{`a/2`, `b/2`}, 2, ``},
+		{`sync`, []string{`a/1`, `b/1`}, []string{`a/2`, `c/2`}, 1, `out of sync at pair 2`},
+		{`r1 longer`, []string{`a/1`, `b/1`}, []string{`a/2`}, 1, `has more records`},
+		{`r2 longer`, []string{`a/1`}, []string{`a/2`, `b/2`}, 1, `has more records`},
+		{`interleaved`, []string{`a/1`, `a/2`, `b/1`, `b/2`}, nil, 2, ``},
+		{`odd`, []string{`a/1`, `a/2`, `b/1`}, nil, 1, `unpaired read b/1`},
+	}
+	for _, tt := range tests {
+		var p *FastqPairReader
+		var err error
+		if tt.r2 == nil {
+			p, err = OpenInterleavedFastq(writeFastq(t, fastqRecords(tt.r1...)))
+		} else {
+			p, err = OpenFastqPair(writeFastq(t, fastqRecords(tt.r1...)),
+				writeFastq(t, fastqRecords(tt.r2...)))
+		}
+		if err != nil {
+			t.Fatalf(`%s: open failed: %v`, tt.name, err)
+		}
+		defer p.Close()
+		if p.IsInterleaved() != (tt.r2 == nil) {
+			t.Fatalf(`%s: IsInterleaved should be %v`, tt.name, tt.r2 == nil)
+		}
+
+		for {
+			r1, r2, err := p.Next()
+			if err != nil {
+				if tt.err == `` || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf(`%s: unexpected error: %v`, tt.name, err)
+				}
+				break
+			}
+			if r1 == nil {
+				if tt.err != `` {
+					t.Fatalf(`%s: should fail with %q`, tt.name, tt.err)
+				}
+				break
+			}
+			if FastqPairName(r1.Id) != FastqPairName(r2.Id) {
+				t.Fatalf(`%s: mismatched pair %s %s`, tt.name, r1.Id, r2.Id)
+			}
+		}
+		if p.PairCount() != tt.want {
+			t.Fatalf(`%s: PairCount should be %d but is %d`, tt.name, tt.want, p.PairCount())
+		}
+	}
+}
+
+func TestFastqPairReaderClose(t *testing.T) {
+	file := writeFastq(t, fastqRecords(`a/1`))
+	if _, err := OpenFastqPair(file, file+`.missing`); err == nil {
+		t.Fatalf(`OpenFastqPair with a missing R2 should fail`)
+	}
+
+	p, err := OpenFastqPair(file, writeFastq(t, fastqRecords(`a/2`)))
+	if err != nil {
+		t.Fatalf(`OpenFastqPair failed: %v`, err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf(`Close failed: %v`, err)
+	}
+	if p.R1.file != nil || p.R2.file != nil {
+		t.Fatalf(`Close should close R1 and R2`)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf(`second Close should do nothing but failed: %v`, err)
+	}
+
+	p, err = OpenInterleavedFastq(writeFastq(t, fastqRecords(`a/1`, `a/2`)))
+	if err != nil {
+		t.Fatalf(`OpenInterleavedFastq failed: %v`, err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf(`Close of an interleaved reader failed: %v`, err)
+	}
+}
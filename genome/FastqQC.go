@@ -0,0 +1,503 @@
+package genome
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// QCOptions controls FastqQC. Overrepresented sequences and duplication
+// are estimated, as in FastQC, from the first MaxTracked distinct
+// sequences seen, which bounds the memory used. Reads longer than
+// TruncateLength bases are truncated to TruncateLength for this so
+// that sequencing errors towards the 3' end do not hide duplicates. A
+// TruncateLength of 0 means reads are not truncated. A
+// sequence is overrepresented if it makes up at least
+// OverrepresentedFraction of all reads.
+type QCOptions struct {
+	Encoding                QualityEncoding
+	MaxTracked              int
+	TruncateLength          int
+	OverrepresentedFraction float64
+}
+
+// NewQCOptions returns the FastQC defaults: Phred+33 qualities, 100,000
+// tracked sequences truncated to 50 bases and a 0.1% threshold for
+// overrepresented sequences.
+func NewQCOptions() *QCOptions {
+	return &QCOptions{
+		Encoding:                Phred33,
+		MaxTracked:              100000,
+		TruncateLength:          50,
+		OverrepresentedFraction: 0.001,
+	}
+}
+
+// The highest quality score that FastqQC records separately. Higher
+// scores are counted as this score.
+const qcMaxQuality = 93
+
+// FastqQC collects quality control metrics from a stream of reads in a
+// single pass. Memory use depends only on the length of the longest
+// read and MaxTracked.
+type FastqQC struct {
+	opts      *QCOptions
+	reads     int64
+	bases     int64
+	minLength int
+	maxLength int
+	lengths   map[int]int64
+	quals     [][qcMaxQuality + 1]int64 // per position
+	comp      [][5]int64                // per position A,C,G,T,N
+	gc        [101]int64                // reads by GC%
+	tracked   map[string]int64
+	trackedN  int64 // reads counted in tracked
+}
+
+// NewFastqQC returns an empty FastqQC. If opts is nil, NewQCOptions is
+// used.
+func NewFastqQC(opts *QCOptions) *FastqQC {
+	if opts == nil {
+		opts = NewQCOptions()
+	}
+	return &FastqQC{opts: opts,
+		lengths: make(map[int]int64),
+		tracked: make(map[string]int64)}
+}
+
+// Add adds a read to the metrics.
+func (qc *FastqQC) Add(r *FastqRec) {
+	l := len(r.Bases)
+	if qc.reads == 0 || l < qc.minLength {
+		qc.minLength = l
+	}
+	if l > qc.maxLength {
+		qc.maxLength = l
+	}
+	qc.reads++
+	qc.bases += int64(l)
+	qc.lengths[l]++
+	for len(qc.comp) < l {
+		qc.comp = append(qc.comp, [5]int64{})
+		qc.quals = append(qc.quals, [qcMaxQuality + 1]int64{})
+	}
+
+	offset := qc.opts.Encoding.Offset()
+	gc, acgt := 0, 0
+	for i, b := range r.Bases {
+		switch toUpper(b) {
+		case 'A':
+			qc.comp[i][0]++
+			acgt++
+		case 'C':
+			qc.comp[i][1]++
+			acgt++
+			gc++
+		case 'G':
+			qc.comp[i][2]++
+			acgt++
+			gc++
+		case 'T':
+			qc.comp[i][3]++
+			acgt++
+		default:
+			qc.comp[i][4]++
+		}
+		if i < len(r.Qualities) {
+			q := int(r.Qualities[i]) - offset
+			if q < 0 {
+				q = 0
+			}
+			if q > qcMaxQuality {
+				q = qcMaxQuality
+			}
+			qc.quals[i][q]++
+		}
+	}
+	if acgt > 0 {
+		qc.gc[(100*gc+acgt/2)/acgt]++
+	}
+
+	seq := string(r.Bases)
+	if qc.opts.TruncateLength > 0 && l > qc.opts.TruncateLength {
+		seq = seq[:qc.opts.TruncateLength]
+	}
+	if _, ok := qc.tracked[seq]; ok || len(qc.tracked) < qc.opts.MaxTracked {
+		qc.tracked[seq]++
+		qc.trackedN++
+	}
+}
+
+// QCReport holds the metrics from FastqQC. Percentages are of reads or
+// bases as appropriate. Positions are 1-based.
+type QCReport struct {
+	Name             string                `json:"name"`
+	Encoding         string                `json:"encoding"`
+	Reads            int64                 `json:"reads"`
+	Bases            int64                 `json:"bases"`
+	MinLength        int                   `json:"min_length"`
+	MaxLength        int                   `json:"max_length"`
+	MeanLength       float64               `json:"mean_length"`
+	GCPercent        float64               `json:"gc_percent"`
+	NPercent         float64               `json:"n_percent"`
+	MeanQuality      float64               `json:"mean_quality"`
+	Q20Percent       float64               `json:"q20_percent"`
+	Q30Percent       float64               `json:"q30_percent"`
+	PositionQuality  []*QCPositionQuality  `json:"position_quality"`
+	PositionBases    []*QCPositionBases    `json:"position_bases"`
+	GCDistribution   []int64               `json:"gc_distribution"`
+	Lengths          []*QCLengthCount      `json:"lengths"`
+	Overrepresented  []*QCOverrepresented  `json:"overrepresented"`
+	Duplication      []*QCDuplicationLevel `json:"duplication"`
+	DuplicatePercent float64               `json:"duplicate_percent"`
+}
+
+// QCPositionQuality is the distribution of quality scores at a position.
+type QCPositionQuality struct {
+	Position      int     `json:"position"`
+	Mean          float64 `json:"mean"`
+	Median        int     `json:"median"`
+	LowerQuartile int     `json:"lower_quartile"`
+	UpperQuartile int     `json:"upper_quartile"`
+	P10           int     `json:"p10"`
+	P90           int     `json:"p90"`
+}
+
+// QCPositionBases is the base composition at a position.
+type QCPositionBases struct {
+	Position int     `json:"position"`
+	A        float64 `json:"a"`
+	C        float64 `json:"c"`
+	G        float64 `json:"g"`
+	T        float64 `json:"t"`
+	N        float64 `json:"n"`
+}
+
+// QCLengthCount is the number of reads of a length.
+type QCLengthCount struct {
+	Length int   `json:"length"`
+	Count  int64 `json:"count"`
+}
+
+// QCOverrepresented is an overrepresented sequence.
+type QCOverrepresented struct {
+	Sequence string  `json:"sequence"`
+	Count    int64   `json:"count"`
+	Percent  float64 `json:"percent"`
+}
+
+// QCDuplicationLevel is the percentage of tracked reads whose sequence
+// is seen a number of times in the Level range, e.g. 1, 2 or >10.
+type QCDuplicationLevel struct {
+	Level   string  `json:"level"`
+	Percent float64 `json:"percent"`
+}
+
+// Duplication levels as in FastQC. A sequence seen n times falls in the
+// last level whose lower bound is <= n.
+var qcDupLevels = []struct {
+	name string
+	min  int64
+}{
+	{`1`, 1}, {`2`, 2}, {`3`, 3}, {`4`, 4}, {`5`, 5}, {`6`, 6}, {`7`, 7},
+	{`8`, 8}, {`9`, 9}, {`>10`, 10}, {`>50`, 50}, {`>100`, 100},
+	{`>500`, 500}, {`>1k`, 1000}, {`>5k`, 5000}, {`>10k`, 10000},
+}
+
+func percent(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// Report summarises the reads added so far.
+func (qc *FastqQC) Report(name string) *QCReport {
+	rep := &QCReport{Name: name, Encoding: qc.opts.Encoding.String(),
+		Reads: qc.reads, Bases: qc.bases,
+		MinLength: qc.minLength, MaxLength: qc.maxLength}
+	if qc.reads > 0 {
+		rep.MeanLength = float64(qc.bases) / float64(qc.reads)
+	}
+
+	var gc, acgt, n, qsum, qn, q20, q30 int64
+	for i := range qc.comp {
+		c := qc.comp[i]
+		total := c[0] + c[1] + c[2] + c[3] + c[4]
+		rep.PositionBases = append(rep.PositionBases, &QCPositionBases{
+			Position: i + 1,
+			A:        percent(c[0], total),
+			C:        percent(c[1], total),
+			G:        percent(c[2], total),
+			T:        percent(c[3], total),
+			N:        percent(c[4], total),
+		})
+		gc += c[1] + c[2]
+		acgt += c[0] + c[1] + c[2] + c[3]
+		n += c[4]
+
+		h := qc.quals[i][:]
+		var sum, count int64
+		for q, k := range h {
+			sum += int64(q) * k
+			count += k
+			if q >= 20 {
+				q20 += k
+			}
+			if q >= 30 {
+				q30 += k
+			}
+		}
+		pq := &QCPositionQuality{Position: i + 1,
+			Median:        quantile(h, count, 0.5),
+			LowerQuartile: quantile(h, count, 0.25),
+			UpperQuartile: quantile(h, count, 0.75),
+			P10:           quantile(h, count, 0.1),
+			P90:           quantile(h, count, 0.9)}
+		if count > 0 {
+			pq.Mean = float64(sum) / float64(count)
+		}
+		rep.PositionQuality = append(rep.PositionQuality, pq)
+		qsum += sum
+		qn += count
+	}
+	rep.GCPercent = percent(gc, acgt)
+	rep.NPercent = percent(n, qc.bases)
+	if qn > 0 {
+		rep.MeanQuality = float64(qsum) / float64(qn)
+	}
+	rep.Q20Percent = percent(q20, qn)
+	rep.Q30Percent = percent(q30, qn)
+	rep.GCDistribution = append([]int64{}, qc.gc[:]...)
+
+	for l, c := range qc.lengths {
+		rep.Lengths = append(rep.Lengths, &QCLengthCount{l, c})
+	}
+	sort.Slice(rep.Lengths, func(i, j int) bool {
+		return rep.Lengths[i].Length < rep.Lengths[j].Length
+	})
+
+	levels := make([]int64, len(qcDupLevels))
+	for seq, c := range qc.tracked {
+		if float64(c) >= qc.opts.OverrepresentedFraction*float64(qc.reads) {
+			rep.Overrepresented = append(rep.Overrepresented,
+				&QCOverrepresented{seq, c, percent(c, qc.reads)})
+		}
+		for i := len(qcDupLevels) - 1; i >= 0; i-- {
+			if c >= qcDupLevels[i].min {
+				levels[i] += c
+				break
+			}
+		}
+	}
+	sort.Slice(rep.Overrepresented, func(i, j int) bool {
+		a, b := rep.Overrepresented[i], rep.Overrepresented[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Sequence < b.Sequence
+	})
+	for i, l := range qcDupLevels {
+		rep.Duplication = append(rep.Duplication,
+			&QCDuplicationLevel{l.name, percent(levels[i], qc.trackedN)})
+	}
+	if qc.trackedN > 0 {
+		rep.DuplicatePercent = 100 - percent(int64(len(qc.tracked)), qc.trackedN)
+	}
+	return rep
+}
+
+// quantile returns the smallest score that at least fraction p of the
+// count scores in histogram h are less than or equal to.
+func quantile(h []int64, count int64, p float64) int {
+	if count == 0 {
+		return 0
+	}
+	var cum int64
+	for q, k := range h {
+		cum += k
+		if float64(cum) >= p*float64(count) {
+			return q
+		}
+	}
+	return len(h) - 1
+}
+
+// QC reads the remaining records of the FASTQ file and returns a
+// QCReport. If opts is nil, NewQCOptions is used.
+func (f *FastqFile) QC(opts *QCOptions) (*QCReport, error) {
+	qc := NewFastqQC(opts)
+	for {
+		r, err := f.Next()
+		if err != nil {
+			return nil, fmt.Errorf("genome.FastqFile.QC: %w", err)
+		}
+		if r == nil {
+			break
+		}
+		qc.Add(r)
+	}
+	return qc.Report(f.Filepath), nil
+}
+
+// JSON returns the QCReport as indented JSON.
+func (rep *QCReport) JSON() ([]byte, error) {
+	j, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("genome.QCReport.JSON: %w", err)
+	}
+	return j, nil
+}
+
+// Size of the charts in the HTML report
+const (
+	qcChartWidth  = 600
+	qcChartHeight = 200
+)
+
+// qcPoints returns the points of an SVG polyline that plots values
+// with a y axis from 0 to ymax.
+func qcPoints(values []float64, ymax float64) string {
+	var sb strings.Builder
+	dx := float64(qcChartWidth)
+	if len(values) > 1 {
+		dx /= float64(len(values) - 1)
+	}
+	for i, v := range values {
+		if ymax > 0 {
+			v = v / ymax
+		}
+		fmt.Fprintf(&sb, "%.1f,%.1f ", float64(i)*dx, qcChartHeight*(1-v))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+type qcLine struct {
+	Colour string
+	Label  string
+	Points string
+}
+
+type qcChart struct {
+	Title string
+	YMax  float64
+	Lines []qcLine
+}
+
+// charts returns the line charts for the HTML report.
+func (rep *QCReport) charts() []qcChart {
+	var mean, p10, p90 []float64
+	for _, pq := range rep.PositionQuality {
+		mean = append(mean, pq.Mean)
+		p10 = append(p10, float64(pq.P10))
+		p90 = append(p90, float64(pq.P90))
+	}
+	qmax := 41.0
+	for _, v := range p90 {
+		if v > qmax {
+			qmax = v
+		}
+	}
+	comp := make([][]float64, 5)
+	for _, pb := range rep.PositionBases {
+		for i, v := range []float64{pb.A, pb.C, pb.G, pb.T, pb.N} {
+			comp[i] = append(comp[i], v)
+		}
+	}
+	var gc []float64
+	gcmax := 0.0
+	for _, c := range rep.GCDistribution {
+		gc = append(gc, float64(c))
+		if float64(c) > gcmax {
+			gcmax = float64(c)
+		}
+	}
+
+	return []qcChart{
+		{`Quality by position`, qmax, []qcLine{
+			{`#2c7bb6`, `mean`, qcPoints(mean, qmax)},
+			{`#d7191c`, `10th percentile`, qcPoints(p10, qmax)},
+			{`#1a9641`, `90th percentile`, qcPoints(p90, qmax)}}},
+		{`Base composition by position (%)`, 100, []qcLine{
+			{`#1a9641`, `A`, qcPoints(comp[0], 100)},
+			{`#2c7bb6`, `C`, qcPoints(comp[1], 100)},
+			{`#000000`, `G`, qcPoints(comp[2], 100)},
+			{`#d7191c`, `T`, qcPoints(comp[3], 100)},
+			{`#999999`, `N`, qcPoints(comp[4], 100)}}},
+		{`GC content of reads (0-100%)`, gcmax, []qcLine{
+			{`#2c7bb6`, `reads`, qcPoints(gc, gcmax)}}},
+	}
+}
+
+var qcTemplate = template.Must(template.New(`qc`).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>QC report: {{.Report.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: right; }
+th { background: #eee; }
+td.seq { font-family: monospace; text-align: left; }
+svg { border: 1px solid #ccc; margin-bottom: 0.5em; }
+</style>
+</head>
+<body>
+<h1>QC report: {{.Report.Name}}</h1>
+<h2>Summary</h2>
+<table>
+<tr><th>Encoding</th><td>{{.Report.Encoding}}</td></tr>
+<tr><th>Reads</th><td>{{.Report.Reads}}</td></tr>
+<tr><th>Bases</th><td>{{.Report.Bases}}</td></tr>
+<tr><th>Length</th><td>{{.Report.MinLength}}-{{.Report.MaxLength}} (mean {{printf "%.1f" .Report.MeanLength}})</td></tr>
+<tr><th>GC%</th><td>{{printf "%.2f" .Report.GCPercent}}</td></tr>
+<tr><th>N%</th><td>{{printf "%.2f" .Report.NPercent}}</td></tr>
+<tr><th>Mean quality</th><td>{{printf "%.1f" .Report.MeanQuality}}</td></tr>
+<tr><th>Q20 bases %</th><td>{{printf "%.2f" .Report.Q20Percent}}</td></tr>
+<tr><th>Q30 bases %</th><td>{{printf "%.2f" .Report.Q30Percent}}</td></tr>
+<tr><th>Duplicate reads %</th><td>{{printf "%.2f" .Report.DuplicatePercent}}</td></tr>
+</table>
+{{range .Charts}}
+<h2>{{.Title}}</h2>
+<svg width="{{$.Width}}" height="{{$.Height}}" viewBox="0 0 {{$.Width}} {{$.Height}}">
+{{range .Lines}}<polyline fill="none" stroke="{{.Colour}}" stroke-width="1.5" points="{{.Points}}"/>
+{{end}}</svg>
+<div>y axis 0-{{printf "%.0f" .YMax}}; {{range .Lines}}<span style="color: {{.Colour}}">&#9632; {{.Label}}</span> {{end}}</div>
+{{end}}
+<h2>Read lengths</h2>
+<table>
+<tr><th>Length</th><th>Reads</th></tr>
+{{range .Report.Lengths}}<tr><td>{{.Length}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<h2>Duplication levels</h2>
+<table>
+<tr><th>Level</th><th>% of reads</th></tr>
+{{range .Report.Duplication}}<tr><td>{{.Level}}</td><td>{{printf "%.2f" .Percent}}</td></tr>
+{{end}}</table>
+<h2>Overrepresented sequences</h2>
+{{if .Report.Overrepresented}}<table>
+<tr><th>Sequence</th><th>Count</th><th>%</th></tr>
+{{range .Report.Overrepresented}}<tr><td class="seq">{{.Sequence}}</td><td>{{.Count}}</td><td>{{printf "%.2f" .Percent}}</td></tr>
+{{end}}</table>{{else}}<p>None</p>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the QCReport as a self-contained HTML page with
+// inline SVG charts.
+func (rep *QCReport) WriteHTML(w io.Writer) error {
+	data := struct {
+		Report *QCReport
+		Charts []qcChart
+		Width  int
+		Height int
+	}{rep, rep.charts(), qcChartWidth, qcChartHeight}
+	if err := qcTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("genome.QCReport.WriteHTML: %w", err)
+	}
+	return nil
+}
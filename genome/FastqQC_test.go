@@ -0,0 +1,129 @@
+package genome
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFastqQC(t *testing.T) {
+	content := "@r1\nACGT\n+\nIIII\n" +
+		"@r2\nACGT\n+\nIIII\n" +
+		"@r3\nGGCC\n+\n+++5\n" +
+		"@r4\nAANNTT\n+\n######\n"
+	ff, err := OpenFastqFile(writeFastq(t, content))
+	if err != nil {
+		t.Fatalf(`OpenFastqFile failed: %v`, err)
+	}
+	defer ff.Close()
+	opts := NewQCOptions()
+	opts.OverrepresentedFraction = 0.5
+	rep, err := ff.QC(opts)
+	if err != nil {
+		t.Fatalf(`QC failed: %v`, err)
+	}
+
+	if rep.Reads != 4 || rep.Bases != 18 || rep.MinLength != 4 || rep.MaxLength != 6 {
+		t.Fatalf(`QC counts incorrect: %d reads, %d bases, lengths %d-%d`,
+			rep.Reads, rep.Bases, rep.MinLength, rep.MaxLength)
+	}
+	// GC: 2+2+4+0 of 16 ACGT bases
+	if rep.GCPercent != 50 {
+		t.Fatalf(`GCPercent should be 50 but is %f`, rep.GCPercent)
+	}
+	if rep.PositionBases[2].N != 25 || rep.PositionBases[4].N != 0 {
+		t.Fatalf(`N at position 3 should be 25%% but is %f`, rep.PositionBases[2].N)
+	}
+	// Position 1 qualities are 40, 40, 10, 2
+	pq := rep.PositionQuality[0]
+	if pq.Mean != 23 || pq.Median != 10 || pq.LowerQuartile != 2 || pq.P90 != 40 {
+		t.Fatalf(`position 1 quality incorrect: %+v`, pq)
+	}
+	// Q30: 8 of 18
+	if rep.Q30Percent != 100*8.0/18 {
+		t.Fatalf(`Q30Percent should be %f but is %f`, 100*8.0/18, rep.Q30Percent)
+	}
+	if rep.GCDistribution[50] != 2 || rep.GCDistribution[100] != 1 || rep.GCDistribution[0] != 1 {
+		t.Fatalf(`GCDistribution incorrect: %v`, rep.GCDistribution)
+	}
+	if len(rep.Lengths) != 2 || rep.Lengths[0].Length != 4 || rep.Lengths[0].Count != 3 {
+		t.Fatalf(`Lengths incorrect`)
+	}
+	if len(rep.Overrepresented) != 1 || rep.Overrepresented[0].Sequence != `ACGT` ||
+		rep.Overrepresented[0].Percent != 50 {
+		t.Fatalf(`Overrepresented should be ACGT at 50%%`)
+	}
+	// 3 distinct sequences in 4 reads
+	if rep.DuplicatePercent != 25 || rep.Duplication[0].Percent != 50 || rep.Duplication[1].Percent != 50 {
+		t.Fatalf(`duplication incorrect: %f %f %f`, rep.DuplicatePercent,
+			rep.Duplication[0].Percent, rep.Duplication[1].Percent)
+	}
+
+	j, err := rep.JSON()
+	if err != nil {
+		t.Fatalf(`JSON failed: %v`, err)
+	}
+	var back QCReport
+	if err := json.Unmarshal(j, &back); err != nil || back.Reads != 4 {
+		t.Fatalf(`JSON did not round trip: %v`, err)
+	}
+
+	var sb strings.Builder
+	if err := rep.WriteHTML(&sb); err != nil {
+		t.Fatalf(`WriteHTML failed: %v`, err)
+	}
+	for _, s := range []string{`<polyline`, `<td class="seq">ACGT</td>`, `Q30 bases`} {
+		if !strings.Contains(sb.String(), s) {
+			t.Fatalf(`HTML report should contain %s`, s)
+		}
+	}
+}
+
+func TestFastqQCTracking(t *testing.T) {
+	opts := NewQCOptions()
+	opts.MaxTracked = 2
+	qc := NewFastqQC(opts)
+	long := strings.Repeat(`ACGT`, 25)
+	for _, s := range []string{`AAAA`, `CCCC`, `GGGG`, `AAAA`, long, long + `A`} {
+		qc.Add(trimRec(s, ``))
+	}
+	rep := qc.Report(`test`)
+	// GGGG and the long reads are not tracked
+	if math.Abs(rep.DuplicatePercent-100.0/3) > 1e-9 {
+		t.Fatalf(`DuplicatePercent should be 33.3 but is %f`, rep.DuplicatePercent)
+	}
+
+	qc = NewFastqQC(nil)
+	qc.Add(trimRec(long, ``))
+	qc.Add(trimRec(long[:80]+`TTTT`, ``))
+	// Both truncate to the same 50 bases
+	if rep := qc.Report(`test`); rep.DuplicatePercent != 50 {
+		t.Fatalf(`truncated reads should be duplicates but DuplicatePercent is %f`, rep.DuplicatePercent)
+	}
+}
+
+func TestFastqQCTruncate(t *testing.T) {
+	opts := NewQCOptions()
+	opts.TruncateLength = 100
+	qc := NewFastqQC(opts)
+	long := strings.Repeat(`ACGT`, 30)
+	// Shorter than, equal to and longer than TruncateLength
+	for _, s := range []string{long[:80], long[:80], long[:100], long, long[:100] + `TTTT`} {
+		qc.Add(trimRec(s, ``))
+	}
+	rep := qc.Report(`test`)
+	// Distinct: 80 bases, and 100 bases which all of the rest truncate to
+	if math.Abs(rep.DuplicatePercent-60) > 1e-9 {
+		t.Fatalf(`DuplicatePercent should be 60 but is %f`, rep.DuplicatePercent)
+	}
+
+	opts = NewQCOptions()
+	opts.TruncateLength = 0
+	qc = NewFastqQC(opts)
+	qc.Add(trimRec(long, ``))
+	qc.Add(trimRec(long+`A`, ``))
+	if rep := qc.Report(`test`); rep.DuplicatePercent != 0 {
+		t.Fatalf(`untruncated reads should not be duplicates but DuplicatePercent is %f`, rep.DuplicatePercent)
+	}
+}
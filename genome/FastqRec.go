@@ -69,16 +69,44 @@ func (r *FastqRec) SetQualitiesFromString(s string) {
 	r.Qualities = []byte(s)
 }
 
-// CheckValid checks that a Record has an Id and that the count of Bases
-// and Qualities is the same. Note that a Record with no Bases and no
+// CheckValid checks that a Record has an Id, that the count of Bases
+// and Qualities is the same and that every quality character is in the
+// Sanger range ! to ~. Note that a Record with no Bases and no
 // Qualities is considered valid.
 func (r *FastqRec) CheckValid() error {
+	if r.Id == `` {
+		return fmt.Errorf("read has no Id")
+	}
 	if len(r.Bases) != len(r.Qualities) {
 		return fmt.Errorf("base and quality score counts do not match for read: %s", r.Id)
 	}
+	for i, q := range r.Qualities {
+		if q < '!' || q > '~' {
+			return fmt.Errorf("invalid quality character %q at position %d for read: %s", q, i+1, r.Id)
+		}
+	}
 	return nil
 }
 
+// repair fixes the problems that FastqFile.Next will repair in
+// FastqRepair mode: quality characters outside the Sanger range are
+// replaced with ! and Bases and Qualities are trimmed to the same
+// length. It returns true if the Record is then valid.
+func (r *FastqRec) repair() bool {
+	for i, q := range r.Qualities {
+		if q < '!' || q > '~' {
+			r.Qualities[i] = '!'
+		}
+	}
+	if len(r.Bases) > len(r.Qualities) {
+		r.Bases = r.Bases[:len(r.Qualities)]
+	}
+	if len(r.Qualities) > len(r.Bases) {
+		r.Qualities = r.Qualities[:len(r.Bases)]
+	}
+	return r.CheckValid() == nil
+}
+
 // String returns a 4-line string representation of the Record with "\n"
 // as the line-ending and "+" by itself for line 3.
 func (r *FastqRec) String() string {
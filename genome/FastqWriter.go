@@ -0,0 +1,204 @@
+package genome
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Compression selects how a FastqWriter compresses its output.
+type Compression int
+
+const (
+	// AutoCompression uses Gzip for files with a .gz extension and
+	// NoCompression otherwise.
+	AutoCompression Compression = iota
+	NoCompression
+	Gzip
+	// Bgzip writes BGZF, the blocked gzip format from htslib, which
+	// any gzip reader can read.
+	Bgzip
+)
+
+// FastqWriterOptions controls a FastqWriter. If RepeatId is true the
+// Id is repeated on the + line. Level is the gzip compression level
+// (see compress/gzip) where 0, the zero value, means
+// gzip.DefaultCompression rather than gzip.NoCompression; to write
+// uncompressed FASTQ use the NoCompression Compression. BufferSize is
+// the size of the write buffer.
+// If Validate is true, each record is checked with FastqRec.CheckValid
+// before it is written.
+type FastqWriterOptions struct {
+	Compression Compression
+	Level       int
+	BufferSize  int
+	RepeatId    bool
+	Validate    bool
+}
+
+// NewFastqWriterOptions returns the defaults: compression based on the
+// file extension at the default gzip level, a 1MB buffer, a bare +
+// line and validation.
+func NewFastqWriterOptions() *FastqWriterOptions {
+	return &FastqWriterOptions{
+		Compression: AutoCompression,
+		Level:       gzip.DefaultCompression,
+		BufferSize:  1024 * 1024,
+		Validate:    true,
+	}
+}
+
+// FastqWriter writes FastqRec to a file or io.Writer. Close must be
+// called to flush the buffer and finish any compression.
+type FastqWriter struct {
+	Filepath string
+	opts     *FastqWriterOptions
+	w        *bufio.Writer
+	zw       interface {
+		io.Writer
+		Flush() error
+		Close() error
+	}
+	file   *os.File
+	recCtr int
+}
+
+// CreateFastqWriter creates (or truncates) file and returns a
+// FastqWriter for it. If opts is nil, NewFastqWriterOptions is used.
+func CreateFastqWriter(file string, opts *FastqWriterOptions) (*FastqWriter, error) {
+	if opts == nil {
+		opts = NewFastqWriterOptions()
+	}
+	o := *opts
+	if o.Compression == AutoCompression {
+		o.Compression = NoCompression
+		if isGzipFile(file) {
+			o.Compression = Gzip
+		}
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("genome.CreateFastqWriter: %w", err)
+	}
+	fw, err := NewFastqWriter(f, &o)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("genome.CreateFastqWriter: %w", err)
+	}
+	fw.Filepath = file
+	fw.file = f
+	return fw, nil
+}
+
+// NewFastqWriter returns a FastqWriter that writes to w. If opts is
+// nil, NewFastqWriterOptions is used and AutoCompression means
+// NoCompression. Closing the FastqWriter does not close w.
+func NewFastqWriter(w io.Writer, opts *FastqWriterOptions) (*FastqWriter, error) {
+	if opts == nil {
+		opts = NewFastqWriterOptions()
+	}
+	fw := &FastqWriter{opts: opts}
+	level := opts.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var err error
+	switch opts.Compression {
+	case Gzip:
+		fw.zw, err = gzip.NewWriterLevel(w, level)
+	case Bgzip:
+		fw.zw, err = newBgzfWriter(w, level)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("genome.NewFastqWriter: %w", err)
+	}
+	if fw.zw != nil {
+		w = fw.zw
+	}
+
+	size := opts.BufferSize
+	if size < 4096 {
+		size = 4096
+	}
+	fw.w = bufio.NewWriterSize(w, size)
+	return fw, nil
+}
+
+// Write writes one record.
+func (fw *FastqWriter) Write(r *FastqRec) error {
+	if fw.opts.Validate {
+		if err := r.CheckValid(); err != nil {
+			return fmt.Errorf("genome.FastqWriter.Write: record %d: %w", fw.recCtr+1, err)
+		}
+	}
+	w := fw.w
+	w.WriteByte('@')
+	w.WriteString(r.Id)
+	w.WriteByte('\n')
+	w.Write(r.Bases)
+	w.WriteString("\n+")
+	if fw.opts.RepeatId {
+		w.WriteString(r.Id)
+	}
+	w.WriteByte('\n')
+	w.Write(r.Qualities)
+	// bufio.Writer errors are sticky so checking the last write is
+	// enough
+	if err := w.WriteByte('\n'); err != nil {
+		return fmt.Errorf("genome.FastqWriter.Write: %w", err)
+	}
+	fw.recCtr++
+	return nil
+}
+
+// WriteBatch writes records in order and stops at the first error.
+func (fw *FastqWriter) WriteBatch(recs []*FastqRec) error {
+	for _, r := range recs {
+		if err := fw.Write(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordCount returns the number of records written.
+func (fw *FastqWriter) RecordCount() int {
+	return fw.recCtr
+}
+
+// Flush writes any buffered records through to the underlying writer.
+func (fw *FastqWriter) Flush() error {
+	if err := fw.w.Flush(); err != nil {
+		return fmt.Errorf("genome.FastqWriter.Flush: %w", err)
+	}
+	if fw.zw != nil {
+		if err := fw.zw.Flush(); err != nil {
+			return fmt.Errorf("genome.FastqWriter.Flush: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close flushes the buffer, finishes any compression and closes the
+// file if the FastqWriter was created with CreateFastqWriter.
+func (fw *FastqWriter) Close() error {
+	err := fw.w.Flush()
+	if fw.zw != nil {
+		if zerr := fw.zw.Close(); err == nil {
+			err = zerr
+		}
+	}
+	if fw.file != nil {
+		if ferr := fw.file.Close(); err == nil {
+			err = ferr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("genome.FastqWriter.Close: %w", err)
+	}
+	return nil
+}
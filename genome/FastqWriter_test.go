@@ -0,0 +1,160 @@
+package genome
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testFastqRecs(n int) []*FastqRec {
+	var recs []*FastqRec
+	for i := 0; i < n; i++ {
+		r := NewFastqRec()
+		r.Id = fmt.Sprintf("read%d 1:N:0:ACGT", i+1)
+		r.SetBasesFromString(strings.Repeat("ACGTN", 20))
+		r.SetQualitiesFromString(strings.Repeat("IIII#", 20))
+		recs = append(recs, r)
+	}
+	return recs
+}
+
+func TestFastqWriter(t *testing.T) {
+	recs := testFastqRecs(2000)
+	tests := []struct {
+		file string
+		comp Compression
+	}{
+		{`plain.fq`, AutoCompression},
+		{`gzip.fq.gz`, AutoCompression},
+		{`bgzip.fq.gz`, Bgzip},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), tt.file)
+		opts := NewFastqWriterOptions()
+		opts.Compression = tt.comp
+		fw, err := CreateFastqWriter(file, opts)
+		if err != nil {
+			t.Fatalf(`CreateFastqWriter(%s) failed: %v`, tt.file, err)
+		}
+		if err := fw.WriteBatch(recs); err != nil {
+			t.Fatalf(`WriteBatch(%s) failed: %v`, tt.file, err)
+		}
+		if err := fw.Close(); err != nil {
+			t.Fatalf(`Close(%s) failed: %v`, tt.file, err)
+		}
+		if fw.RecordCount() != len(recs) {
+			t.Fatalf(`RecordCount(%s) should be %d but is %d`, tt.file, len(recs), fw.RecordCount())
+		}
+
+		ff, err := OpenFastqFile(file)
+		if err != nil {
+			t.Fatalf(`OpenFastqFile(%s) failed: %v`, tt.file, err)
+		}
+		defer ff.Close()
+		for i := 0; ; i++ {
+			r, err := ff.Next()
+			if err != nil {
+				t.Fatalf(`Next(%s) failed: %v`, tt.file, err)
+			}
+			if r == nil {
+				if i != len(recs) {
+					t.Fatalf(`%s should have %d records but has %d`, tt.file, len(recs), i)
+				}
+				break
+			}
+			if r.Id != recs[i].Id || string(r.Bases) != string(recs[i].Bases) {
+				t.Fatalf(`%s record %d incorrect: %s`, tt.file, i+1, r.String())
+			}
+		}
+	}
+}
+
+func TestFastqWriterRepeatId(t *testing.T) {
+	var b bytes.Buffer
+	opts := NewFastqWriterOptions()
+	opts.RepeatId = true
+	fw, err := NewFastqWriter(&b, opts)
+	if err != nil {
+		t.Fatalf(`NewFastqWriter failed: %v`, err)
+	}
+	r, _ := FastqRecFromString("@r1\nACGT\n+\nIIII\n")
+	if err := fw.Write(r); err != nil {
+		t.Fatalf(`Write failed: %v`, err)
+	}
+	fw.Close()
+	e := "@r1\nACGT\n+r1\nIIII\n"
+	if b.String() != e {
+		t.Fatalf(`RepeatId output should be %q but is %q`, e, b.String())
+	}
+
+	r.Qualities = r.Qualities[:2]
+	if err := fw.Write(r); err == nil {
+		t.Fatalf(`Write of an invalid record should fail`)
+	}
+}
+
+func TestBgzfBlocks(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.fq.gz")
+	opts := NewFastqWriterOptions()
+	opts.Compression = Bgzip
+	fw, err := CreateFastqWriter(file, opts)
+	if err != nil {
+		t.Fatalf(`CreateFastqWriter failed: %v`, err)
+	}
+	fw.WriteBatch(testFastqRecs(2000))
+	fw.Close()
+
+	// Size of the uncompressed data
+	var plain bytes.Buffer
+	opts.Compression = NoCompression
+	pw, _ := NewFastqWriter(&plain, opts)
+	pw.WriteBatch(testFastqRecs(2000))
+	pw.Close()
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf(`unable to read %s: %v`, file, err)
+	}
+	// Walk the blocks using the BSIZE in each header
+	blocks := 0
+	for off := 0; off < len(b); blocks++ {
+		if b[off] != 0x1f || b[off+1] != 0x8b || b[off+12] != 'B' || b[off+13] != 'C' {
+			t.Fatalf(`block %d at %d is not a BGZF block`, blocks, off)
+		}
+		off += int(binary.LittleEndian.Uint16(b[off+16:])) + 1
+	}
+	// Full data blocks, a short final data block and the EOF block
+	e := plain.Len()/bgzfBlockSize + 2
+	if blocks != e {
+		t.Fatalf(`BGZF file should have %d blocks but has %d`, e, blocks)
+	}
+	if !bytes.HasSuffix(b, bgzfEOF) {
+		t.Fatalf(`BGZF file should end with the EOF block`)
+	}
+}
+
+func TestFastqWriterZeroLevel(t *testing.T) {
+	recs := testFastqRecs(2000)
+	var plain bytes.Buffer
+	fw, _ := NewFastqWriter(&plain, nil)
+	fw.WriteBatch(recs)
+	fw.Close()
+
+	for _, comp := range []Compression{Gzip, Bgzip} {
+		// A zero Level must compress, not store
+		var b bytes.Buffer
+		fw, err := NewFastqWriter(&b, &FastqWriterOptions{Compression: comp})
+		if err != nil {
+			t.Fatalf(`NewFastqWriter failed: %v`, err)
+		}
+		fw.WriteBatch(recs)
+		fw.Close()
+		if b.Len() >= plain.Len()/10 {
+			t.Fatalf(`compression %d with Level 0 wrote %d bytes for %d of FASTQ`, comp, b.Len(), plain.Len())
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package genome
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// nRuns returns the 0-based half-open limits of every run of at least
+// minLength N (or n) bases in seq.
+func nRuns(seq string, minLength int) [][2]int {
+	return byteRuns(seq, minLength, func(b byte) bool {
+		return b == 'N' || b == 'n'
+	})
+}
+
+// byteRuns returns the 0-based half-open limits of every run of at
+// least minLength consecutive bytes in seq for which in returns true.
+func byteRuns(seq string, minLength int, in func(byte) bool) [][2]int {
+	var runs [][2]int
+	start := -1
+	for i := 0; i <= len(seq); i++ {
+		if i < len(seq) && in(seq[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minLength {
+			runs = append(runs, [2]int{start, i})
+		}
+		start = -1
+	}
+	return runs
+}
+
+// Gaps returns a gff3.Feature of Type gap for every run of at least
+// minLength Ns in the FastaRec. A minLength of less than 1 is treated
+// as 1. The Length attribute holds the number of Ns in the run.
+func (r *FastaRec) Gaps(minLength int) []*gff3.Feature {
+	var feats []*gff3.Feature
+	if minLength < 1 {
+		minLength = 1
+	}
+	for _, run := range nRuns(r.Sequence, minLength) {
+		f := gff3.NewFeature()
+		f.SeqId = r.Name
+		f.Source = `grz-gap`
+		f.Type = `gap`
+		f.Start = run[0] + 1
+		f.End = run[1]
+		f.Attributes[`Length`] = strconv.Itoa(run[1] - run[0])
+		feats = append(feats, f)
+	}
+	return feats
+}
+
+// Gaps returns the gaps of at least minLength Ns in every sequence of
+// the Genome as a sorted gff3.Features. See FastaRec.Gaps.
+func (g *Genome) Gaps(minLength int) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(r *FastaRec) error {
+		fs.AddFeatures(r.Gaps(minLength)...)
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.Gaps: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
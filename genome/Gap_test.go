@@ -0,0 +1,29 @@
+package genome
+
+import (
+	"testing"
+)
+
+func TestGaps(t *testing.T) {
+	r := NewFastaRec(">chrT")
+	r.Sequence = `NNACGTNACGTnnnnACGTNN`
+
+	feats := r.Gaps(1)
+	if len(feats) != 4 {
+		t.Fatalf(`Gaps(1) should find 4 gaps but found %d`, len(feats))
+	}
+	e := [][2]int{{1, 2}, {7, 7}, {12, 15}, {20, 21}}
+	for i, f := range feats {
+		if f.Start != e[i][0] || f.End != e[i][1] || f.Type != `gap` || f.SeqId != `chrT` {
+			t.Fatalf(`gap %d should be chrT:%d-%d but is %s`, i, e[i][0], e[i][1], f.String())
+		}
+	}
+	if feats[2].Attributes[`Length`] != `4` {
+		t.Fatalf(`gap 2 Length should be 4 but is %s`, feats[2].Attributes[`Length`])
+	}
+
+	feats = r.Gaps(3)
+	if len(feats) != 1 || feats[0].Start != 12 {
+		t.Fatalf(`Gaps(3) should find 1 gap at 12 but found %d`, len(feats))
+	}
+}
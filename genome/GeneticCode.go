@@ -0,0 +1,241 @@
+package genome
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GeneticCode is an NCBI translation table. AminoAcids and Starts are
+// copied from the NCBI gc.prt file and each hold one character per
+// codon with the codons in the NCBI order, i.e. TTT, TTC, TTA, TTG,
+// TCT ... GGG with bases ordered T, C, A, G. In Starts, M marks a
+// codon that can act as an initiator.
+// See https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi
+type GeneticCode struct {
+	Id         int
+	Name       string
+	AminoAcids string
+	Starts     string
+}
+
+var geneticCodes = map[int]*GeneticCode{
+	1: {1, "Standard",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**--*----M---------------M----------------------------"},
+	2: {2, "Vertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+		"----------**--------------------MMMM----------**---M------------"},
+	3: {3, "Yeast Mitochondrial",
+		"FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**----------------------MM---------------M------------"},
+	4: {4, "Mold, Protozoan, and Coelenterate Mitochondrial and Mycoplasma/Spiroplasma",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--MM------**-------M------------MMMM---------------M------------"},
+	5: {5, "Invertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG",
+		"---M------**--------------------MMMM---------------M------------"},
+	6: {6, "Ciliate, Dasycladacean and Hexamita Nuclear",
+		"FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	9: {9, "Echinoderm and Flatworm Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"----------**-----------------------M---------------M------------"},
+	10: {10, "Euplotid Nuclear",
+		"FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**-----------------------M----------------------------"},
+	11: {11, "Bacterial, Archaeal and Plant Plastid",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**--*----M------------MMMM---------------M------------"},
+	12: {12, "Alternative Yeast Nuclear",
+		"FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*----M---------------M----------------------------"},
+	13: {13, "Ascidian Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG",
+		"---M------**----------------------MM---------------M------------"},
+	14: {14, "Alternative Flatworm Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"-----------*-----------------------M----------------------------"},
+	16: {16, "Chlorophycean Mitochondrial",
+		"FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------*---*--------------------M----------------------------"},
+	21: {21, "Trematode Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"----------**-----------------------M---------------M------------"},
+	22: {22, "Scenedesmus obliquus Mitochondrial",
+		"FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"------*---*---*--------------------M----------------------------"},
+	23: {23, "Thraustochytrium Mitochondrial",
+		"FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--*-------**--*-----------------M--M---------------M------------"},
+	24: {24, "Rhabdopleuridae Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M------**-------M---------------M---------------M------------"},
+	25: {25, "Candidate Division SR1 and Gracilibacteria",
+		"FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**-----------------------M---------------M------------"},
+	26: {26, "Pachysolen tannophilus Nuclear",
+		"FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*----M---------------M----------------------------"},
+	27: {27, "Karyorelict Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	28: {28, "Condylostoma Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*--------------------M----------------------------"},
+	29: {29, "Mesodinium Nuclear",
+		"FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	30: {30, "Peritrich Nuclear",
+		"FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	31: {31, "Blastocrithidia Nuclear",
+		"FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**-----------------------M----------------------------"},
+	32: {32, "Balanophoraceae Plastid",
+		"FFLLSSSSYY*WCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------*---*----M------------MMMM---------------M------------"},
+	33: {33, "Cephalodiscidae Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M-------*-------M---------------M---------------M------------"},
+}
+
+// codonIndex gives the position of each base in the NCBI T, C, A, G
+// codon ordering.
+var codonIndex = map[byte]int{'T': 0, 'C': 1, 'A': 2, 'G': 3}
+
+// GeneticCodeById returns the NCBI translation table with the given Id,
+// e.g. 1 for the standard code or 2 for vertebrate mitochondria.
+func GeneticCodeById(id int) (*GeneticCode, error) {
+	gc, ok := geneticCodes[id]
+	if !ok {
+		return nil, fmt.Errorf("genome.GeneticCodeById: no NCBI translation table with id %d", id)
+	}
+	return gc, nil
+}
+
+// GeneticCodeIds returns the Ids of all of the available translation
+// tables in ascending order.
+func GeneticCodeIds() []int {
+	var ids []int
+	for id := range geneticCodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// AminoAcid translates a single codon. The codon may be DNA or RNA,
+// upper or lower case, and may contain IUPAC ambiguity codes. An
+// ambiguous codon is translated if every codon it could represent
+// gives the same amino acid, e.g. CTN is L, otherwise X is returned.
+// X is also returned for anything that is not a 3 base codon.
+func (gc *GeneticCode) AminoAcid(codon string) byte {
+	return gc.lookup(gc.AminoAcids, codon, 'X')
+}
+
+// IsStart reports whether the codon is an initiator in this table. An
+// ambiguous codon is only a start if every codon it could represent is
+// a start.
+func (gc *GeneticCode) IsStart(codon string) bool {
+	return gc.lookup(gc.Starts, codon, '-') == 'M'
+}
+
+// IsStop reports whether the codon is a stop in this table. An
+// ambiguous codon is only a stop if every codon it could represent is
+// a stop.
+func (gc *GeneticCode) IsStop(codon string) bool {
+	return gc.AminoAcid(codon) == '*'
+}
+
+// lookup expands an ambiguous codon into all of the codons it could
+// represent and returns the character from table if they all agree
+// and missing if they do not.
+func (gc *GeneticCode) lookup(table, codon string, missing byte) byte {
+	if len(codon) != 3 {
+		return missing
+	}
+	var found byte
+	for _, b1 := range iupacBases[toUpper(codon[0])] {
+		for _, b2 := range iupacBases[toUpper(codon[1])] {
+			for _, b3 := range iupacBases[toUpper(codon[2])] {
+				i := codonIndex[byte(b1)]*16 + codonIndex[byte(b2)]*4 + codonIndex[byte(b3)]
+				c := table[i]
+				if found != 0 && c != found {
+					return missing
+				}
+				found = c
+			}
+		}
+	}
+	if found == 0 {
+		return missing
+	}
+	return found
+}
+
+// TranslateOptions controls Sequence.Translate.
+//
+// Table is the NCBI translation table Id. Frame is 1, 2 or 3 to start
+// translating at that base of the plus strand or -1, -2 or -3 to
+// translate the reverse complement from the corresponding base.
+// If Initiator is true and the first codon is a start codon in Table,
+// it is translated as M even if it would otherwise code for a
+// different amino acid, e.g. TTG or, in mitochondria, ATA. If ToStop
+// is true, translation ends at the first stop codon and the stop is
+// not included in the protein.
+type TranslateOptions struct {
+	Table     int
+	Frame     int
+	Initiator bool
+	ToStop    bool
+}
+
+// NewTranslateOptions returns TranslateOptions for translating frame 1
+// with the standard code and no special handling of starts or stops.
+func NewTranslateOptions() *TranslateOptions {
+	return &TranslateOptions{Table: 1, Frame: 1}
+}
+
+// Translate returns a protein Sequence, with the same Name, translated
+// from this Sequence according to opts. If opts is nil, the defaults
+// from NewTranslateOptions are used. Stop codons are shown as * and
+// any bases left over after the last full codon are ignored.
+func (s *Sequence) Translate(opts *TranslateOptions) (*Sequence, error) {
+	if opts == nil {
+		opts = NewTranslateOptions()
+	}
+	gc, err := GeneticCodeById(opts.Table)
+	if err != nil {
+		return nil, fmt.Errorf("genome.Sequence.Translate: %w", err)
+	}
+	if err := s.Validate(IUPAC); err != nil {
+		return nil, fmt.Errorf("genome.Sequence.Translate: %w", err)
+	}
+
+	seq := s.Sequence
+	frame := opts.Frame
+	switch {
+	case frame >= 1 && frame <= 3:
+	case frame <= -1 && frame >= -3:
+		seq = string(reverseComplement([]byte(seq)))
+		frame = -frame
+	default:
+		return nil, fmt.Errorf("genome.Sequence.Translate: frame must be 1, 2, 3, -1, -2 or -3: %d", opts.Frame)
+	}
+
+	var b strings.Builder
+	for i := frame - 1; i+3 <= len(seq); i += 3 {
+		codon := seq[i : i+3]
+		aa := gc.AminoAcid(codon)
+		if i == frame-1 && opts.Initiator && gc.IsStart(codon) {
+			aa = 'M'
+		}
+		if aa == '*' && opts.ToStop {
+			break
+		}
+		b.WriteByte(aa)
+	}
+
+	return &Sequence{Name: s.Name, Sequence: b.String()}, nil
+}
@@ -0,0 +1,60 @@
+package genome
+
+import (
+	"testing"
+)
+
+func TestGeneticCodeTables(t *testing.T) {
+	for _, id := range GeneticCodeIds() {
+		gc, err := GeneticCodeById(id)
+		if err != nil {
+			t.Fatalf(`GeneticCodeById(%d) failed: %v`, id, err)
+		}
+		if len(gc.AminoAcids) != 64 || len(gc.Starts) != 64 {
+			t.Fatalf(`table %d should have 64 codons but has %d/%d`,
+				id, len(gc.AminoAcids), len(gc.Starts))
+		}
+	}
+	if _, err := GeneticCodeById(7); err == nil {
+		t.Fatalf(`GeneticCodeById(7) should have failed`)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name string
+		seq  string
+		opts *TranslateOptions
+		want string
+	}{
+		{"standard", `ATGTGAAGAtt`, &TranslateOptions{Table: 1, Frame: 1}, `M*R`},
+		{"vert mito", `ATGTGAAGA`, &TranslateOptions{Table: 2, Frame: 1}, `MW*`},
+		{"rna", `AUGUGAAGA`, &TranslateOptions{Table: 1, Frame: 1}, `M*R`},
+		{"frame 2", `cATGAAA`, &TranslateOptions{Table: 1, Frame: 2}, `MK`},
+		{"frame -1", `TTTCAT`, &TranslateOptions{Table: 1, Frame: -1}, `MK`},
+		{"frame -3", `TTTCATgg`, &TranslateOptions{Table: 1, Frame: -3}, `MK`},
+		{"to stop", `ATGTAAAAA`, &TranslateOptions{Table: 1, Frame: 1, ToStop: true}, `M`},
+		{"ATA start", `ATAAAA`, &TranslateOptions{Table: 2, Frame: 1, Initiator: true}, `MK`},
+		{"ATA not start", `ATAAAA`, &TranslateOptions{Table: 1, Frame: 1, Initiator: true}, `IK`},
+		{"TTG start", `TTGTTG`, &TranslateOptions{Table: 11, Frame: 1, Initiator: true}, `ML`},
+		{"ambiguous", `CTNYTARAYNNN`, nil, `LLXX`},
+		{"ambiguous stop", `TRA`, nil, `*`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Sequence{Name: "test", Sequence: tc.seq}
+			p, err := s.Translate(tc.opts)
+			if err != nil {
+				t.Fatalf(`Translate failed: %v`, err)
+			}
+			if p.Sequence != tc.want {
+				t.Fatalf(`Translate of %s should be %s but is %s`, tc.seq, tc.want, p.Sequence)
+			}
+		})
+	}
+
+	s := &Sequence{Name: "test", Sequence: `ATGAAA`}
+	if _, err := s.Translate(&TranslateOptions{Table: 1, Frame: 4}); err == nil {
+		t.Fatalf(`Translate with frame 4 should have failed`)
+	}
+}
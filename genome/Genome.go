@@ -7,8 +7,11 @@ import (
 	"encoding/gob"
 	"fmt"
 	"os"
+	"sort"
+	"sync"
 
 	"github.com/google/uuid"
+	"github.com/grendeloz/ngs/region"
 	"github.com/grendeloz/runp"
 	log "github.com/sirupsen/logrus"
 )
@@ -25,6 +28,17 @@ type Genome struct {
 	FastaFiles map[string]string
 	Provenance []runp.RunParameters
 	Version    string
+
+	// lazy is only set for Genomes from NewLazyGenome
+	lazy *lazyStore
+
+	// seqIndex maps sequence names to their position in Sequences. It
+	// is rebuilt when the number of Sequences differs from seqIndexLen,
+	// when a lookup misses or finds a different name, and after Rename
+	// or ApplySelectors.
+	seqIndex    map[string]int
+	seqIndexLen int
+	seqIndexMu  sync.Mutex
 }
 
 func NewGenome(name string) *Genome {
@@ -66,12 +80,12 @@ func (g *Genome) NewSeed(seed string) (*Seed, error) {
 	//}
 
 	// Add Sequences from Genome
-	for _, s := range g.Sequences {
+	err := g.eachSequence(func(s *FastaRec) error {
 		log.Infof("  adding sequence %s to Seed", s.Header)
-		err := gs.addSequence(s)
-		if err != nil {
-			return gs, fmt.Errorf("genome.Genome.NewSeed: %w", err)
-		}
+		return gs.addSequence(s)
+	})
+	if err != nil {
+		return gs, fmt.Errorf("genome.Genome.NewSeed: %w", err)
 	}
 
 	// Apply Seed
@@ -86,16 +100,23 @@ func (g *Genome) AddFastaFile(file string) error {
 	if err != nil {
 		return fmt.Errorf("genome.Genome.AddFastaFile: %w", err)
 	}
+	return g.AddFasta(ff)
+}
 
+// AddFasta adds the remaining records from an open FastaFile to the
+// Genome. Use this rather than AddFastaFile when the FastaFile has
+// selectors or renames. If it does, a new Provenance record is added
+// whose Args are genome.Genome.AddFasta, the file, the selectors and
+// old=new for each rename.
+func (g *Genome) AddFasta(ff *FastaFile) error {
 	// Add filepath and MD5
 	md5, err := ff.MD5()
 	if err != nil {
 		return fmt.Errorf("error calculating MD5 from FASTA file: %w", err)
 	}
-	g.FastaFiles[file] = md5
+	g.FastaFiles[ff.Filepath] = md5
 
 	// Add to Genome
-	//g.Sequences = append(g.Sequences, seqs...)
 	var fr *FastaRec
 	for {
 		fr, err = ff.Next()
@@ -108,26 +129,109 @@ func (g *Genome) AddFastaFile(file string) error {
 		g.Sequences = append(g.Sequences, fr)
 	}
 
+	if len(ff.selectors) > 0 || len(ff.renames) > 0 {
+		args := []string{`genome.Genome.AddFasta`, ff.Filepath}
+		for _, rs := range ff.selectors {
+			args = append(args, rs.sel.String())
+		}
+		var renames []string
+		for o, n := range ff.renames {
+			renames = append(renames, o+`=`+n)
+		}
+		sort.Strings(renames)
+		g.addProvenanceArgs(append(args, renames...))
+	}
+
 	return nil
 }
 
 // GetSequence returns a *Sequence or an error if the named sequence is
 // not found. Note that the match is exact so case, spaces etc all
-// matter - perfect match or no match.
+// matter - perfect match or no match. If more than one sequence has
+// the name, the first is returned. Lookups use a map that is rebuilt
+// when the number of Sequences changes, after Genome.Rename and
+// ApplySelectors, and when a lookup finds a different name or no name,
+// so sequences replaced or renamed in place are still found. A lazy
+// Genome loads the sequence if it is not in the cache.
 func (g *Genome) GetSequence(seqName string) (*FastaRec, error) {
-	for _, s := range g.Sequences {
-		if seqName == s.Name {
-			return s, nil
+	if g.lazy != nil {
+		r, err := g.lazy.getSequence(seqName)
+		if err != nil {
+			return nil, fmt.Errorf("genome.Genome.GetSequence: genome %s: %w", g.Name, err)
 		}
+		return r, nil
+	}
+
+	g.seqIndexMu.Lock()
+	defer g.seqIndexMu.Unlock()
+	rebuilt := false
+	if g.seqIndex == nil || g.seqIndexLen != len(g.Sequences) {
+		g.indexSequences()
+		rebuilt = true
+	}
+	i, ok := g.seqIndex[seqName]
+	if !rebuilt && (!ok || g.Sequences[i].Name != seqName) {
+		// Sequences may have been changed in place since the map was
+		// built so rebuild it and try again.
+		g.indexSequences()
+		i, ok = g.seqIndex[seqName]
+	}
+	if !ok {
+		return nil, fmt.Errorf("Sequence %s not found in genome %s", seqName, g.Name)
 	}
-	return nil, fmt.Errorf("Sequence %s not found in genome %s", seqName, g.Name)
+	return g.Sequences[i], nil
+}
+
+// indexSequences rebuilds seqIndex. The caller must hold seqIndexMu.
+func (g *Genome) indexSequences() {
+	g.seqIndex = make(map[string]int, len(g.Sequences))
+	for i := len(g.Sequences) - 1; i >= 0; i-- {
+		g.seqIndex[g.Sequences[i].Name] = i
+	}
+	g.seqIndexLen = len(g.Sequences)
+}
+
+// resetSeqIndex makes the next GetSequence rebuild seqIndex.
+func (g *Genome) resetSeqIndex() {
+	g.seqIndexMu.Lock()
+	g.seqIndex = nil
+	g.seqIndexMu.Unlock()
+}
+
+// Fetch returns the bases covered by the Region as a Sequence named
+// with the Region string. If the Region is on the - strand, the
+// sequence is reverse complemented. A Region without a start or end
+// extends to the start or end of the sequence.
+func (g *Genome) Fetch(r *region.Region) (*Sequence, error) {
+	l, err := g.SequenceLength(r.SeqId)
+	if err != nil {
+		return nil, fmt.Errorf("genome.Genome.Fetch: %w", err)
+	}
+	rr, err := r.Resolve(l)
+	if err != nil {
+		return nil, fmt.Errorf("genome.Genome.Fetch: %w", err)
+	}
+	bases, err := g.SubSequence(rr.SeqId, rr.Start, rr.End)
+	if err != nil {
+		return nil, fmt.Errorf("genome.Genome.Fetch: %w", err)
+	}
+	s := &Sequence{Name: r.String(), Sequence: bases}
+	if rr.Strand == `-` {
+		s = s.ReverseComplement()
+	}
+	return s, nil
 }
 
 // WriteAsGob serialises a genome to disk. The caller can specify the
 // stem of the output filename but some identifying information is
-// appended including the UUID. The filename is returned.
+// appended including the UUID. The filename is returned. It is an
+// error to write a lazy Genome because its sequences are not in
+// Sequences; call LoadAll first.
 func (g *Genome) WriteAsGob(filestem string) (string, error) {
 	file := filestem + "." + g.UUID + ".genome.gob"
+	if g.lazy != nil {
+		return file, fmt.Errorf("genome.Genome.WriteAsGob: genome %s is lazy so call LoadAll first", g.Name)
+	}
 
 	f, err := os.Create(file)
 	if err != nil {
@@ -2,6 +2,8 @@ package genome
 
 import (
 	"testing"
+
+	"github.com/grendeloz/ngs/region"
 )
 
 func TestGenomeAddFastaFile(t *testing.T) {
@@ -40,3 +42,95 @@ func TestGenomeAddFastaFile(t *testing.T) {
 		t.Fatalf(`seq 0 FastaFile.Filepath incorrect - should be %v but is %v`, e3, g3)
 	}
 }
+
+func TestGenomeGetSequence(t *testing.T) {
+	g := NewGenome(`test`)
+	for _, h := range []string{`>chr1`, `>chr2`, `>chr1`} {
+		g.Sequences = append(g.Sequences, NewFastaRec(h))
+	}
+	g.Sequences[0].Sequence = `first`
+
+	r, err := g.GetSequence(`chr1`)
+	if err != nil {
+		t.Fatalf(`GetSequence(chr1) failed: %v`, err)
+	}
+	if r.Sequence != `first` {
+		t.Fatalf(`GetSequence(chr1) should return the first chr1`)
+	}
+
+	// Changes to Sequences must be seen by later lookups
+	g.Sequences = append(g.Sequences, NewFastaRec(`>chr3`))
+	if _, err := g.GetSequence(`chr3`); err != nil {
+		t.Fatalf(`GetSequence(chr3) failed after append: %v`, err)
+	}
+	g.Sequences[1].Rename(`chrX`)
+	if _, err := g.GetSequence(`chr2`); err == nil {
+		t.Fatalf(`GetSequence(chr2) should fail after rename`)
+	}
+	if _, err := g.GetSequence(`chrX`); err != nil {
+		t.Fatalf(`GetSequence(chrX) failed after rename: %v`, err)
+	}
+	if _, err := g.GetSequence(`chrZ`); err == nil {
+		t.Fatalf(`GetSequence(chrZ) should fail`)
+	}
+	g.Sequences = g.Sequences[1:]
+	if r, _ := g.GetSequence(`chr1`); r == nil || r.Sequence == `first` {
+		t.Fatalf(`GetSequence(chr1) should return the remaining chr1`)
+	}
+	if _, err := g.Rename(map[string]string{`chr3`: `chrY`}); err != nil {
+		t.Fatalf(`Rename failed: %v`, err)
+	}
+	if _, err := g.GetSequence(`chrY`); err != nil {
+		t.Fatalf(`GetSequence(chrY) failed after Genome.Rename: %v`, err)
+	}
+	// Renaming or replacing in place, looking up the new name first
+	g.Sequences[0].Rename(`chrW`)
+	if r, err := g.GetSequence(`chrW`); err != nil || r != g.Sequences[0] {
+		t.Fatalf(`GetSequence(chrW) failed after rename in place: %v`, err)
+	}
+	g.Sequences[2] = NewFastaRec(`>chrV`)
+	if r, err := g.GetSequence(`chrV`); err != nil || r != g.Sequences[2] {
+		t.Fatalf(`GetSequence(chrV) failed after replacement in place: %v`, err)
+	}
+	if _, err := g.GetSequence(`chrY`); err == nil {
+		t.Fatalf(`GetSequence(chrY) should fail after replacement`)
+	}
+}
+
+func TestGenomeFetch(t *testing.T) {
+	g := NewGenome(`test`)
+	r := NewFastaRec(`>chr1`)
+	r.Sequence = `AACCGGTTAC`
+	g.Sequences = append(g.Sequences, r)
+
+	tests := []struct {
+		region string
+		e      string
+	}{
+		{`chr1`, `AACCGGTTAC`},
+		{`chr1:3-6`, `CCGG`},
+		{`chr1:8`, `TAC`},
+		{`chr1:1-3:-`, `GTT`},
+		{`chr1:7-10:+`, `TTAC`},
+	}
+	for _, tt := range tests {
+		reg, err := region.Parse(tt.region)
+		if err != nil {
+			t.Fatalf(`region.Parse(%s) failed: %v`, tt.region, err)
+		}
+		s, err := g.Fetch(reg)
+		if err != nil {
+			t.Fatalf(`Fetch(%s) failed: %v`, tt.region, err)
+		}
+		if s.Sequence != tt.e || s.Name != reg.String() {
+			t.Fatalf(`Fetch(%s) should be %s but is %s %s`, tt.region, tt.e, s.Name, s.Sequence)
+		}
+	}
+
+	for _, b := range []string{`chr1:5-11`, `chr2:1-5`} {
+		reg, _ := region.Parse(b)
+		if _, err := g.Fetch(reg); err == nil {
+			t.Fatalf(`Fetch(%s) should fail`, b)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package genome
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// A SequenceSource gives random access to the sequences in a file
+// without reading them into memory. IndexedFasta and TwoBit are
+// SequenceSources. Fetch uses 0-based half-open coordinates.
+type SequenceSource interface {
+	Names() []string
+	Length(name string) (int, error)
+	Fetch(name string, start, end int) (string, error)
+}
+
+// A HeaderSource is a SequenceSource that also has the FASTA header
+// line of each sequence. IndexedFasta is a HeaderSource. The sequences
+// of a lazy Genome over any other SequenceSource have their name as
+// their header.
+type HeaderSource interface {
+	Header(name string) (string, error)
+}
+
+// DefaultCacheBases is the default size of the sequence cache of a lazy
+// Genome, enough for the largest human chromosome.
+const DefaultCacheBases = 256 * 1024 * 1024
+
+// lazyStore is the state of a lazy Genome. The cache holds complete
+// FastaRec and is limited by the total number of bases it holds.
+// Sequences changed through the Genome, for example by ApplyMask, are
+// moved to edited which is never evicted so the changes are not lost.
+type lazyStore struct {
+	source   SequenceSource
+	names    []string
+	lengths  map[string]int
+	maxBases int
+
+	// sourceNames maps each name to its name in source, which differs
+	// once a sequence has been renamed.
+	sourceNames map[string]string
+	// headers holds the header lines from a HeaderSource.
+	headers map[string]string
+
+	mu     sync.Mutex
+	bases  int
+	lru    *list.List // of *FastaRec, most recently used at the front
+	cached map[string]*list.Element
+	edited map[string]*FastaRec
+}
+
+// NewLazyGenome returns a Genome that records the names and lengths of
+// the sequences in source but only reads the bases when they are asked
+// for. GetSequence loads a complete sequence and keeps it in a least
+// recently used cache that holds at most cacheBases bases (a
+// cacheBases of less than 1 means DefaultCacheBases). Sequences longer
+// than the cache are returned but not cached. SubSequence reads only
+// the requested bases and does not use the cache.
+//
+// Sequences is empty in a lazy Genome. Methods that work on every
+// sequence, for example Stats and FindMotifs, load the sequences one at
+// a time through the cache and return an error if one cannot be read.
+// Sequences changed by HardMask, Unmask or ApplyMask are kept in memory
+// outside the cache, so masking a whole lazy Genome holds all of it in
+// memory. ApplySelectors and Rename change only the sequence table.
+// If source is a HeaderSource, the header lines are read when the
+// Genome is created so selectors on header and info behave as they do
+// for a Genome that is not lazy.
+// WriteAsGob returns an error for a lazy Genome so call LoadAll first.
+func NewLazyGenome(name string, source SequenceSource, cacheBases int) (*Genome, error) {
+	if cacheBases < 1 {
+		cacheBases = DefaultCacheBases
+	}
+	ls := &lazyStore{
+		source:      source,
+		names:       source.Names(),
+		lengths:     make(map[string]int),
+		sourceNames: make(map[string]string),
+		headers:     make(map[string]string),
+		maxBases:    cacheBases,
+		lru:         list.New(),
+		cached:      make(map[string]*list.Element),
+		edited:      make(map[string]*FastaRec),
+	}
+	for _, n := range ls.names {
+		l, err := source.Length(n)
+		if err != nil {
+			return nil, fmt.Errorf("genome.NewLazyGenome: %w", err)
+		}
+		ls.lengths[n] = l
+		ls.sourceNames[n] = n
+		if hs, ok := source.(HeaderSource); ok {
+			if ls.headers[n], err = hs.Header(n); err != nil {
+				return nil, fmt.Errorf("genome.NewLazyGenome: %w", err)
+			}
+		}
+	}
+
+	g := NewGenome(name)
+	g.lazy = ls
+	switch s := source.(type) {
+	case *IndexedFasta:
+		g.FastaFiles[s.Filepath] = ``
+	case *TwoBit:
+		g.FastaFiles[s.Filepath] = ``
+	}
+	return g, nil
+}
+
+// OpenLazyGenome opens file as a lazy Genome. Files with a .2bit
+// extension are opened with OpenTwoBit and all other files with
+// OpenIndexedFasta so they must be uncompressed FASTA. A gob Genome
+// (see Genome.WriteAsGob) has no index so it cannot be read lazily;
+// read it with GenomeFromGob or convert it to .2bit with WriteTwoBit.
+func OpenLazyGenome(name, file string, cacheBases int) (*Genome, error) {
+	var src SequenceSource
+	var err error
+	switch lc := strings.ToLower(file); {
+	case strings.HasSuffix(lc, `.2bit`):
+		src, err = OpenTwoBit(file)
+	case strings.HasSuffix(lc, `.gob`):
+		err = fmt.Errorf("%s is a gob Genome which cannot be read lazily", file)
+	default:
+		src, err = OpenIndexedFasta(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("genome.OpenLazyGenome: %w", err)
+	}
+	return NewLazyGenome(name, src, cacheBases)
+}
+
+// IsLazy returns true if the Genome loads sequences on demand.
+func (g *Genome) IsLazy() bool {
+	return g.lazy != nil
+}
+
+// SequenceNames returns the names of the sequences in the Genome, in
+// file order, whether or not they have been loaded.
+func (g *Genome) SequenceNames() []string {
+	if g.lazy != nil {
+		return append([]string{}, g.lazy.names...)
+	}
+	var names []string
+	for _, r := range g.Sequences {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// SequenceLength returns the length of the named sequence without
+// loading it.
+func (g *Genome) SequenceLength(name string) (int, error) {
+	if g.lazy != nil {
+		l, ok := g.lazy.lengths[name]
+		if !ok {
+			return 0, fmt.Errorf("genome.Genome.SequenceLength: sequence %s not found in genome %s", name, g.Name)
+		}
+		return l, nil
+	}
+	r, err := g.GetSequence(name)
+	if err != nil {
+		return 0, fmt.Errorf("genome.Genome.SequenceLength: %w", err)
+	}
+	return r.Length(), nil
+}
+
+// SubSequence returns the bases from start to end, a 1-based closed
+// interval, of the named sequence. A lazy Genome reads only these
+// bases from its SequenceSource.
+func (g *Genome) SubSequence(name string, start, end int) (string, error) {
+	l, err := g.SequenceLength(name)
+	if err != nil {
+		return ``, fmt.Errorf("genome.Genome.SubSequence: %w", err)
+	}
+	if start < 1 || end > l || start > end {
+		return ``, fmt.Errorf("genome.Genome.SubSequence: %d-%d is outside %s (1-%d)", start, end, name, l)
+	}
+	if g.lazy != nil {
+		if r := g.lazy.get(name); r != nil {
+			return r.Sequence[start-1 : end], nil
+		}
+		return g.lazy.source.Fetch(g.lazy.sourceNames[name], start-1, end)
+	}
+	r, _ := g.GetSequence(name)
+	return r.Sequence[start-1 : end], nil
+}
+
+// LoadAll reads every sequence of a lazy Genome into Sequences so the
+// Genome behaves exactly like one built with AddFastaFile. The Genome
+// is no longer lazy and the cache is emptied.
+func (g *Genome) LoadAll() error {
+	if g.lazy == nil {
+		return nil
+	}
+	var seqs []*FastaRec
+	for _, n := range g.lazy.names {
+		r := g.lazy.get(n)
+		if r == nil {
+			var err error
+			if r, err = g.lazy.load(n); err != nil {
+				return fmt.Errorf("genome.Genome.LoadAll: %w", err)
+			}
+		}
+		seqs = append(seqs, r)
+	}
+	g.Sequences = seqs
+	g.lazy = nil
+	return nil
+}
+
+// eachSequence calls fn for each sequence in the Genome in order,
+// stopping at the first error. A lazy Genome loads the sequences one at
+// a time through its cache.
+func (g *Genome) eachSequence(fn func(*FastaRec) error) error {
+	if g.lazy == nil {
+		for _, r := range g.Sequences {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, n := range g.SequenceNames() {
+		r, err := g.lazy.getSequence(n)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sequenceChanged must be called after a sequence of the Genome is
+// changed in place so a lazy Genome keeps the change.
+func (g *Genome) sequenceChanged(r *FastaRec) {
+	if g.lazy != nil {
+		g.lazy.edit(r)
+	}
+}
+
+// Close closes the SequenceSource of a lazy Genome if it has a Close
+// method.
+func (g *Genome) Close() error {
+	if g.lazy == nil {
+		return nil
+	}
+	if c, ok := g.lazy.source.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// getSequence returns the named sequence from the cache or, on a cache
+// miss, from the SequenceSource.
+func (ls *lazyStore) getSequence(name string) (*FastaRec, error) {
+	if r := ls.get(name); r != nil {
+		return r, nil
+	}
+	if _, ok := ls.lengths[name]; !ok {
+		return nil, fmt.Errorf("sequence %s not found", name)
+	}
+	r, err := ls.load(name)
+	if err != nil {
+		return nil, err
+	}
+	ls.put(r)
+	return r, nil
+}
+
+func (ls *lazyStore) load(name string) (*FastaRec, error) {
+	seq, err := ls.source.Fetch(ls.sourceNames[name], 0, ls.lengths[name])
+	if err != nil {
+		return nil, err
+	}
+	r := ls.record(name)
+	r.Sequence = seq
+	return r, nil
+}
+
+// record returns a FastaRec with the header, but not the bases, of the
+// named sequence.
+func (ls *lazyStore) record(name string) *FastaRec {
+	if h, ok := ls.headers[name]; ok {
+		return NewFastaRec(h)
+	}
+	return NewFastaRec(`>` + name)
+}
+
+// get returns the named sequence if it is edited or cached, or nil.
+func (ls *lazyStore) get(name string) *FastaRec {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if r, ok := ls.edited[name]; ok {
+		return r
+	}
+	e, ok := ls.cached[name]
+	if !ok {
+		return nil
+	}
+	ls.lru.MoveToFront(e)
+	return e.Value.(*FastaRec)
+}
+
+// put adds a sequence to the cache, evicting the least recently used
+// sequences to make room.
+func (ls *lazyStore) put(r *FastaRec) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if _, ok := ls.edited[r.Name]; ok {
+		return
+	}
+	if _, ok := ls.cached[r.Name]; ok || r.Length() > ls.maxBases {
+		return
+	}
+	for ls.bases+r.Length() > ls.maxBases {
+		e := ls.lru.Back()
+		old := e.Value.(*FastaRec)
+		ls.lru.Remove(e)
+		delete(ls.cached, old.Name)
+		ls.bases -= old.Length()
+	}
+	ls.cached[r.Name] = ls.lru.PushFront(r)
+	ls.bases += r.Length()
+}
+
+// edit moves a changed sequence out of the cache into edited.
+func (ls *lazyStore) edit(r *FastaRec) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if e, ok := ls.cached[r.Name]; ok {
+		ls.lru.Remove(e)
+		delete(ls.cached, r.Name)
+		ls.bases -= e.Value.(*FastaRec).Length()
+	}
+	ls.edited[r.Name] = r
+	ls.lengths[r.Name] = r.Length()
+}
+
+// retain removes every sequence whose name is not in names.
+func (ls *lazyStore) retain(names []string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	keep := make(map[string]bool)
+	for _, n := range names {
+		keep[n] = true
+	}
+	for _, n := range ls.names {
+		if keep[n] {
+			continue
+		}
+		if e, ok := ls.cached[n]; ok {
+			ls.lru.Remove(e)
+			delete(ls.cached, n)
+			ls.bases -= e.Value.(*FastaRec).Length()
+		}
+		delete(ls.edited, n)
+		delete(ls.lengths, n)
+		delete(ls.sourceNames, n)
+		delete(ls.headers, n)
+	}
+	ls.names = names
+}
+
+// rename renames sequences using names which maps old names to new
+// names. The caller must check that the new names are unique.
+func (ls *lazyStore) rename(names map[string]string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	lengths := make(map[string]int)
+	sourceNames := make(map[string]string)
+	headers := make(map[string]string)
+	cached := make(map[string]*list.Element)
+	edited := make(map[string]*FastaRec)
+	for i, old := range ls.names {
+		n := old
+		if nn, ok := names[old]; ok {
+			n = nn
+		}
+		ls.names[i] = n
+		lengths[n] = ls.lengths[old]
+		sourceNames[n] = ls.sourceNames[old]
+		if h, ok := ls.headers[old]; ok {
+			r := NewFastaRec(h)
+			r.Rename(n)
+			headers[n] = r.Header
+		}
+		if e, ok := ls.cached[old]; ok {
+			e.Value.(*FastaRec).Rename(n)
+			cached[n] = e
+		}
+		if r, ok := ls.edited[old]; ok {
+			r.Rename(n)
+			edited[n] = r
+		}
+	}
+	ls.lengths = lengths
+	ls.sourceNames = sourceNames
+	ls.headers = headers
+	ls.cached = cached
+	ls.edited = edited
+}
+
+// cachedNames returns the names of the cached sequences, most recently
+// used first.
+func (ls *lazyStore) cachedNames() []string {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	var names []string
+	for e := ls.lru.Front(); e != nil; e = e.Next() {
+		names = append(names, e.Value.(*FastaRec).Name)
+	}
+	return names
+}
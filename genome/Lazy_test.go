@@ -0,0 +1,241 @@
+package genome
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grendeloz/ngs/gff3"
+	"github.com/grendeloz/ngs/selector"
+)
+
+func TestLazyGenome(t *testing.T) {
+	// Cache room for chr1 (23 bases) or chr2 (20 bases) but not both
+	g, err := OpenLazyGenome(`test`, writeTestFasta(t), 30)
+	if err != nil {
+		t.Fatalf(`OpenLazyGenome failed: %v`, err)
+	}
+	defer g.Close()
+
+	if !g.IsLazy() || len(g.Sequences) != 0 {
+		t.Fatalf(`OpenLazyGenome should return a lazy Genome with no Sequences`)
+	}
+	names := g.SequenceNames()
+	if len(names) != 4 || names[3] != `chr3` {
+		t.Fatalf(`SequenceNames incorrect: %v`, names)
+	}
+	if l, _ := g.SequenceLength(`chr2`); l != 20 {
+		t.Fatalf(`SequenceLength(chr2) should be 20 but is %d`, l)
+	}
+
+	r, err := g.GetSequence(`chr1`)
+	if err != nil {
+		t.Fatalf(`GetSequence(chr1) failed: %v`, err)
+	}
+	if r.Sequence != `ACGTACGTACGTacgtNNNNACG` {
+		t.Fatalf(`chr1 sequence incorrect: %s`, r.Sequence)
+	}
+	if _, err := g.GetSequence(`chr3`); err != nil {
+		t.Fatalf(`GetSequence(chr3) failed: %v`, err)
+	}
+	if c := g.lazy.cachedNames(); len(c) != 2 || c[0] != `chr3` {
+		t.Fatalf(`cache should hold chr3 and chr1 but holds %v`, c)
+	}
+	if _, err := g.GetSequence(`chr2`); err != nil {
+		t.Fatalf(`GetSequence(chr2) failed: %v`, err)
+	}
+	if c := g.lazy.cachedNames(); len(c) != 2 || c[0] != `chr2` || c[1] != `chr3` {
+		t.Fatalf(`chr1 should have been evicted but cache holds %v`, c)
+	}
+	if _, err := g.GetSequence(`chrZ`); err == nil {
+		t.Fatalf(`GetSequence(chrZ) should fail`)
+	}
+
+	s, err := g.SubSequence(`chr1`, 11, 16)
+	if err != nil {
+		t.Fatalf(`SubSequence failed: %v`, err)
+	}
+	if s != `GTacgt` {
+		t.Fatalf(`SubSequence(chr1,11,16) should be GTacgt but is %s`, s)
+	}
+	if _, err := g.SubSequence(`chr1`, 0, 5); err == nil {
+		t.Fatalf(`SubSequence from 0 should fail`)
+	}
+
+	if err := g.LoadAll(); err != nil {
+		t.Fatalf(`LoadAll failed: %v`, err)
+	}
+	if g.IsLazy() || len(g.Sequences) != 4 || g.Sequences[3].Sequence != `ACGT` {
+		t.Fatalf(`LoadAll should fill Sequences`)
+	}
+	if s, _ := g.SubSequence(`chr2`, 1, 5); s != `TTTTT` {
+		t.Fatalf(`SubSequence after LoadAll should be TTTTT but is %s`, s)
+	}
+}
+
+func TestLazyGenomeTwoBit(t *testing.T) {
+	r := NewFastaRec(`>chrM`)
+	r.Sequence = `GATCACAGGTCTATCACCCTATTAACCACTCACGGGAGCTCTCCATGCAT`
+	file := filepath.Join(t.TempDir(), "test.2bit")
+	if err := WriteTwoBit(file, []*FastaRec{r}); err != nil {
+		t.Fatalf(`WriteTwoBit failed: %v`, err)
+	}
+	g, err := OpenLazyGenome(`test`, file, 0)
+	if err != nil {
+		t.Fatalf(`OpenLazyGenome failed: %v`, err)
+	}
+	defer g.Close()
+	if s, _ := g.SubSequence(`chrM`, 1, 10); s != `GATCACAGGT` {
+		t.Fatalf(`SubSequence(chrM,1,10) should be GATCACAGGT but is %s`, s)
+	}
+}
+
+func TestLazyGenomeMethods(t *testing.T) {
+	file := writeTestFasta(t)
+	g, err := OpenLazyGenome(`test`, file, 30)
+	if err != nil {
+		t.Fatalf(`OpenLazyGenome failed: %v`, err)
+	}
+	defer g.Close()
+	eager := NewGenome(`test`)
+	if err := eager.AddFastaFile(file); err != nil {
+		t.Fatalf(`AddFastaFile failed: %v`, err)
+	}
+
+	as, err := g.Stats()
+	if err != nil {
+		t.Fatalf(`Stats failed: %v`, err)
+	}
+	es, _ := eager.Stats()
+	if as.SequenceCount != 4 || as.TotalLength != es.TotalLength || as.GC != es.GC {
+		t.Fatalf(`lazy Stats should match %+v but is %+v`, es, as)
+	}
+	gaps, err := g.Gaps(1)
+	if err != nil || gaps.Count() != 1 || gaps.Features[0].SeqId != `chr1` {
+		t.Fatalf(`lazy Gaps should find 1 gap in chr1: %v`, err)
+	}
+
+	// Masking chr1 must survive it being evicted from the cache
+	fs := gff3.NewFeatures()
+	f, err := gff3.NewFeatureFromLine("chr1\tRepeatMasker\tdispersed_repeat\t1\t4\t.\t+\t.\tName=AluY")
+	if err != nil {
+		t.Fatalf(`NewFeatureFromLine failed: %v`, err)
+	}
+	fs.AddFeatures(f)
+	if n, err := g.ApplyMask(fs, false); err != nil || n != 1 {
+		t.Fatalf(`ApplyMask should apply 1 Feature but applied %d: %v`, n, err)
+	}
+	for _, n := range []string{`chr2`, `chr3`, `chr2`} {
+		if _, err := g.GetSequence(n); err != nil {
+			t.Fatalf(`GetSequence(%s) failed: %v`, n, err)
+		}
+	}
+	if r, _ := g.GetSequence(`chr1`); r.Sequence != `acgtACGTACGTacgtNNNNACG` {
+		t.Fatalf(`chr1 should still be masked but is %s`, r.Sequence)
+	}
+
+	if n, err := g.Rename(map[string]string{`chr1`: `chrA`, `chr2`: `chrB`}); err != nil || n != 2 {
+		t.Fatalf(`Rename should rename 2 sequences but renamed %d: %v`, n, err)
+	}
+	if _, err := g.GetSequence(`chr1`); err == nil {
+		t.Fatalf(`GetSequence(chr1) should fail after Rename`)
+	}
+	if r, err := g.GetSequence(`chrA`); err != nil || r.Name != `chrA` || r.Sequence[:4] != `acgt` {
+		t.Fatalf(`GetSequence(chrA) should return masked chr1: %v`, err)
+	}
+	if s, err := g.SubSequence(`chrB`, 1, 6); err != nil || s != `TTTTTG` {
+		t.Fatalf(`SubSequence(chrB,1,6) should be TTTTTG but is %s: %v`, s, err)
+	}
+
+	sel, _ := selector.NewFromString(`keep:name:^chr[AB]$`)
+	if err := g.ApplySelectors(sel); err != nil {
+		t.Fatalf(`ApplySelectors failed: %v`, err)
+	}
+	if names := g.SequenceNames(); len(names) != 2 || names[1] != `chrB` {
+		t.Fatalf(`ApplySelectors should keep chrA and chrB but kept %v`, names)
+	}
+	if _, err := g.GetSequence(`chr3`); err == nil {
+		t.Fatalf(`GetSequence(chr3) should fail after ApplySelectors`)
+	}
+	if err := g.HardMask(); err != nil {
+		t.Fatalf(`HardMask failed: %v`, err)
+	}
+	if err := g.LoadAll(); err != nil {
+		t.Fatalf(`LoadAll failed: %v`, err)
+	}
+	if len(g.Sequences) != 2 || g.Sequences[0].Sequence != `NNNNACGTACGTNNNNNNNNACG` {
+		t.Fatalf(`LoadAll should return the renamed and masked sequences`)
+	}
+}
+
+func TestLazyGenomeGob(t *testing.T) {
+	g, err := OpenLazyGenome(`test`, writeTestFasta(t), 0)
+	if err != nil {
+		t.Fatalf(`OpenLazyGenome failed: %v`, err)
+	}
+	defer g.Close()
+	stem := filepath.Join(t.TempDir(), `lazy`)
+	if _, err := g.WriteAsGob(stem); err == nil {
+		t.Fatalf(`WriteAsGob of a lazy Genome should fail`)
+	}
+	if err := g.LoadAll(); err != nil {
+		t.Fatalf(`LoadAll failed: %v`, err)
+	}
+	if _, err := g.WriteAsGob(stem); err != nil {
+		t.Fatalf(`WriteAsGob after LoadAll failed: %v`, err)
+	}
+
+	eager := NewGenome(`test`)
+	if err := eager.AddFastaFile(writeTestFasta(t)); err != nil {
+		t.Fatalf(`AddFastaFile failed: %v`, err)
+	}
+	file, err := eager.WriteAsGob(filepath.Join(t.TempDir(), `test`))
+	if err != nil {
+		t.Fatalf(`WriteAsGob failed: %v`, err)
+	}
+	if _, err := OpenLazyGenome(`test`, file, 0); err == nil || !strings.Contains(err.Error(), `gob`) {
+		t.Fatalf(`OpenLazyGenome of a gob Genome should fail but error is %v`, err)
+	}
+}
+
+func TestLazyGenomeSelectHeader(t *testing.T) {
+	file := writeTestFasta(t)
+	for _, s := range []string{`keep:info:^(first|third)$`, `delete:header:first`} {
+		sel, err := selector.NewFromString(s)
+		if err != nil {
+			t.Fatalf(`NewFromString(%s) failed: %v`, s, err)
+		}
+		eager := NewGenome(`test`)
+		if err := eager.AddFastaFile(file); err != nil {
+			t.Fatalf(`AddFastaFile failed: %v`, err)
+		}
+		lazy, err := OpenLazyGenome(`test`, file, 30)
+		if err != nil {
+			t.Fatalf(`OpenLazyGenome failed: %v`, err)
+		}
+		defer lazy.Close()
+		if err := eager.ApplySelectors(sel); err != nil {
+			t.Fatalf(`ApplySelectors(%s) failed: %v`, s, err)
+		}
+		if err := lazy.ApplySelectors(sel); err != nil {
+			t.Fatalf(`lazy ApplySelectors(%s) failed: %v`, s, err)
+		}
+		e := strings.Join(eager.SequenceNames(), `,`)
+		if l := strings.Join(lazy.SequenceNames(), `,`); l != e || l == `` {
+			t.Fatalf(`lazy %s should keep %s but kept %s`, s, e, l)
+		}
+	}
+
+	g, err := OpenLazyGenome(`test`, file, 30)
+	if err != nil {
+		t.Fatalf(`OpenLazyGenome failed: %v`, err)
+	}
+	defer g.Close()
+	if _, err := g.Rename(map[string]string{`chr1`: `chrA`}); err != nil {
+		t.Fatalf(`Rename failed: %v`, err)
+	}
+	r, err := g.GetSequence(`chrA`)
+	if err != nil || r.Header != `>chrA first` || r.Info != `first` {
+		t.Fatalf(`renamed chr1 should keep its info but has header %q: %v`, r.Header, err)
+	}
+}
@@ -0,0 +1,203 @@
+package genome
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// Most reference FASTA files are soft-masked, i.e. bases in repeats
+// and low-complexity regions are written in lowercase while all other
+// bases are uppercase. Hard-masking replaces the masked bases with N
+// which hides them completely from anything that reads the sequence.
+// FastaFile and Genome keep the case of the bases exactly as read so
+// the functions in this file are the only place that case is given a
+// meaning.
+
+func isSoftMasked(b byte) bool {
+	return b >= 'a' && b <= 'z'
+}
+
+// SoftMaskedRegions returns a gff3.Feature of Type repeat_region for
+// every run of at least minLength lowercase bases in the FastaRec. A
+// minLength of less than 1 is treated as 1.
+func (r *FastaRec) SoftMaskedRegions(minLength int) []*gff3.Feature {
+	var feats []*gff3.Feature
+	if minLength < 1 {
+		minLength = 1
+	}
+	for _, run := range byteRuns(r.Sequence, minLength, isSoftMasked) {
+		f := gff3.NewFeature()
+		f.SeqId = r.Name
+		f.Source = `grz-mask`
+		f.Type = `repeat_region`
+		f.Start = run[0] + 1
+		f.End = run[1]
+		f.Attributes[`Length`] = strconv.Itoa(run[1] - run[0])
+		feats = append(feats, f)
+	}
+	return feats
+}
+
+// SoftMaskedFraction returns the fraction of bases in the FastaRec
+// that are soft-masked.
+func (r *FastaRec) SoftMaskedFraction() float64 {
+	if len(r.Sequence) == 0 {
+		return 0
+	}
+	var n int
+	for i := 0; i < len(r.Sequence); i++ {
+		if isSoftMasked(r.Sequence[i]) {
+			n++
+		}
+	}
+	return float64(n) / float64(len(r.Sequence))
+}
+
+// HardMask replaces every soft-masked (lowercase) base with N.
+func (r *FastaRec) HardMask() {
+	b := []byte(r.Sequence)
+	for i, c := range b {
+		if isSoftMasked(c) {
+			b[i] = 'N'
+		}
+	}
+	r.Sequence = string(b)
+}
+
+// Unmask converts every base to uppercase which removes soft-masking.
+func (r *FastaRec) Unmask() {
+	b := []byte(r.Sequence)
+	for i, c := range b {
+		b[i] = toUpper(c)
+	}
+	r.Sequence = string(b)
+}
+
+// Mask masks the bases from start to end, a 1-based closed interval.
+// If hard is true the bases are replaced with N, otherwise they are
+// converted to lowercase. To mask many intervals use Genome.ApplyMask
+// which copies each sequence only once.
+func (r *FastaRec) Mask(start, end int, hard bool) error {
+	b := []byte(r.Sequence)
+	if err := maskBytes(b, start, end, hard); err != nil {
+		return fmt.Errorf("genome.FastaRec.Mask: %w in %s", err, r.Name)
+	}
+	r.Sequence = string(b)
+	return nil
+}
+
+// maskBytes masks the bases of b from start to end, a 1-based closed
+// interval.
+func maskBytes(b []byte, start, end int, hard bool) error {
+	if start < 1 || end > len(b) || start > end {
+		return fmt.Errorf("%d-%d is outside 1-%d", start, end, len(b))
+	}
+	for i := start - 1; i < end; i++ {
+		if hard {
+			b[i] = 'N'
+		} else if b[i] >= 'A' && b[i] <= 'Z' {
+			b[i] += 'a' - 'A'
+		}
+	}
+	return nil
+}
+
+// SoftMaskedRegions returns the soft-masked regions of every sequence
+// in the Genome as a sorted gff3.Features.
+func (g *Genome) SoftMaskedRegions(minLength int) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(r *FastaRec) error {
+		fs.AddFeatures(r.SoftMaskedRegions(minLength)...)
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.SoftMaskedRegions: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
+
+// HardMask replaces every soft-masked base in the Genome with N. A new
+// Provenance record is added because the Genome no longer matches its
+// FastaFiles.
+func (g *Genome) HardMask() error {
+	err := g.eachSequence(func(r *FastaRec) error {
+		r.HardMask()
+		g.sequenceChanged(r)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("genome.Genome.HardMask: %w", err)
+	}
+	g.AddProvenance()
+	return nil
+}
+
+// Unmask converts every base in the Genome to uppercase. A new
+// Provenance record is added because the Genome no longer matches its
+// FastaFiles.
+func (g *Genome) Unmask() error {
+	err := g.eachSequence(func(r *FastaRec) error {
+		r.Unmask()
+		g.sequenceChanged(r)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("genome.Genome.Unmask: %w", err)
+	}
+	g.AddProvenance()
+	return nil
+}
+
+// ApplyMask masks the region covered by each Feature, for example the
+// repeats from a RepeatMasker GFF3. If hard is true the bases are
+// replaced with N, otherwise they are converted to lowercase. Features
+// whose SeqId is not in the Genome are skipped, which makes it simple
+// to apply a genome-wide mask to a subset of sequences, so the number
+// of Features that were applied is returned. The Features are grouped
+// by SeqId so each sequence is copied only once however many Features
+// it has. A Feature that extends beyond the end of its sequence is an
+// error; the Features of that sequence before it, and of the
+// sequences already processed, will have been applied. A new
+// Provenance record is added if any Features were applied, even when
+// an error is returned.
+func (g *Genome) ApplyMask(fs *gff3.Features, hard bool) (int, error) {
+	var seqIds []string
+	bySeq := make(map[string][]*gff3.Feature)
+	for _, f := range fs.Features {
+		if _, ok := bySeq[f.SeqId]; !ok {
+			seqIds = append(seqIds, f.SeqId)
+		}
+		bySeq[f.SeqId] = append(bySeq[f.SeqId], f)
+	}
+
+	var applied int
+	for _, id := range seqIds {
+		r, err := g.GetSequence(id)
+		if err != nil {
+			continue
+		}
+		b := []byte(r.Sequence)
+		for _, f := range bySeq[id] {
+			if err := maskBytes(b, f.Start, f.End, hard); err != nil {
+				r.Sequence = string(b)
+				g.sequenceChanged(r)
+				if applied > 0 {
+					g.AddProvenance()
+				}
+				return applied, fmt.Errorf("genome.Genome.ApplyMask: Feature at line %d: %w in %s", f.LineNumber, err, id)
+			}
+			applied++
+		}
+		r.Sequence = string(b)
+		g.sequenceChanged(r)
+	}
+	if applied > 0 {
+		g.AddProvenance()
+	}
+	return applied, nil
+}
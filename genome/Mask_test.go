@@ -0,0 +1,124 @@
+package genome
+
+import (
+	"testing"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+func TestSoftMask(t *testing.T) {
+	r := NewFastaRec(">chrT")
+	r.Sequence = `ACgtacGTAcGTaaaa`
+
+	feats := r.SoftMaskedRegions(2)
+	if len(feats) != 2 {
+		t.Fatalf(`SoftMaskedRegions(2) should find 2 regions but found %d`, len(feats))
+	}
+	if feats[0].Start != 3 || feats[0].End != 6 || feats[1].Start != 13 || feats[1].End != 16 {
+		t.Fatalf(`SoftMaskedRegions incorrect: %s %s`, feats[0].String(), feats[1].String())
+	}
+	if f := r.SoftMaskedFraction(); f != 0.5625 {
+		t.Fatalf(`SoftMaskedFraction should be 0.5625 but is %f`, f)
+	}
+
+	h := &FastaRec{Name: "h", Sequence: r.Sequence}
+	h.HardMask()
+	if e := `ACNNNNGTANGTNNNN`; h.Sequence != e {
+		t.Fatalf(`HardMask should give %s but gave %s`, e, h.Sequence)
+	}
+	u := &FastaRec{Name: "u", Sequence: r.Sequence}
+	u.Unmask()
+	if e := `ACGTACGTACGTAAAA`; u.Sequence != e {
+		t.Fatalf(`Unmask should give %s but gave %s`, e, u.Sequence)
+	}
+}
+
+func TestApplyMask(t *testing.T) {
+	g := NewGenome("testing")
+	r := NewFastaRec(">chrT")
+	r.Sequence = `ACGTACGTACGT`
+	g.Sequences = append(g.Sequences, r)
+	nprov := len(g.Provenance)
+
+	fs := gff3.NewFeatures()
+	for _, l := range []string{
+		"chrT\tRepeatMasker\tdispersed_repeat\t2\t4\t.\t+\t.\tName=AluY",
+		"chrU\tRepeatMasker\tdispersed_repeat\t2\t4\t.\t+\t.\tName=AluY",
+	} {
+		f, err := gff3.NewFeatureFromLine(l)
+		if err != nil {
+			t.Fatalf(`NewFeatureFromLine failed: %v`, err)
+		}
+		fs.AddFeatures(f)
+	}
+
+	n, err := g.ApplyMask(fs, false)
+	if err != nil {
+		t.Fatalf(`ApplyMask failed: %v`, err)
+	}
+	if n != 1 {
+		t.Fatalf(`ApplyMask should have applied 1 Feature but applied %d`, n)
+	}
+	if e := `AcgtACGTACGT`; r.Sequence != e {
+		t.Fatalf(`soft ApplyMask should give %s but gave %s`, e, r.Sequence)
+	}
+	if len(g.Provenance) != nprov+1 {
+		t.Fatalf(`ApplyMask should have added a Provenance record`)
+	}
+
+	if _, err := g.ApplyMask(fs, true); err != nil {
+		t.Fatalf(`ApplyMask failed: %v`, err)
+	}
+	if e := `ANNNACGTACGT`; r.Sequence != e {
+		t.Fatalf(`hard ApplyMask should give %s but gave %s`, e, r.Sequence)
+	}
+
+	fs.Features[0].End = 13
+	if _, err := g.ApplyMask(fs, true); err == nil {
+		t.Fatalf(`ApplyMask should have failed on a Feature beyond the sequence end`)
+	}
+
+	// A failure after some Features were applied still adds Provenance
+	r.Sequence = `ACGTACGTACGT`
+	f, err := gff3.NewFeatureFromLine("chrT\tRM\trepeat\t1\t2\t.\t+\t.\t.")
+	if err != nil {
+		t.Fatalf(`NewFeatureFromLine failed: %v`, err)
+	}
+	fs.Features = append([]*gff3.Feature{f}, fs.Features...)
+	nprov = len(g.Provenance)
+	if n, err := g.ApplyMask(fs, false); err == nil || n != 1 {
+		t.Fatalf(`ApplyMask should have applied 1 Feature then failed but applied %d: %v`, n, err)
+	}
+	if e := `acGTACGTACGT`; r.Sequence != e {
+		t.Fatalf(`partial ApplyMask should give %s but gave %s`, e, r.Sequence)
+	}
+	if len(g.Provenance) != nprov+1 {
+		t.Fatalf(`partial ApplyMask should have added a Provenance record`)
+	}
+
+	// Many Features on interleaved sequences
+	r.Sequence = `ACGTACGTACGT`
+	r2 := NewFastaRec(">chrV")
+	r2.Sequence = `ACGTACGT`
+	g.Sequences = append(g.Sequences, r2)
+	fs = gff3.NewFeatures()
+	for _, l := range []string{
+		"chrT	RM	repeat	1	1	.	+	.	.",
+		"chrV	RM	repeat	3	4	.	+	.	.",
+		"chrT	RM	repeat	11	12	.	+	.	.",
+		"chrV	RM	repeat	8	8	.	+	.	.",
+		"chrT	RM	repeat	5	6	.	+	.	.",
+	} {
+		f, err := gff3.NewFeatureFromLine(l)
+		if err != nil {
+			t.Fatalf(`NewFeatureFromLine failed: %v`, err)
+		}
+		fs.AddFeatures(f)
+	}
+	if n, err := g.ApplyMask(fs, false); err != nil || n != 5 {
+		t.Fatalf(`ApplyMask should have applied 5 Features but applied %d: %v`, n, err)
+	}
+	if r.Sequence != `aCGTacGTACgt` || r2.Sequence != `ACgtACGt` {
+		t.Fatalf(`ApplyMask gave %s and %s`, r.Sequence, r2.Sequence)
+	}
+}
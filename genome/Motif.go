@@ -0,0 +1,160 @@
+package genome
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// iupacClasses maps each IUPAC nucleotide code to a regexp character
+// class that matches the bases it represents.
+var iupacClasses = map[byte]string{
+	'A': "A", 'C': "C", 'G': "G", 'T': "T", 'U': "T",
+	'R': "[AG]", 'Y': "[CT]", 'S': "[CG]", 'W': "[AT]",
+	'K': "[GT]", 'M': "[AC]", 'B': "[CGT]", 'D': "[AGT]",
+	'H': "[ACT]", 'V': "[ACG]", 'N': "[ACGT]",
+}
+
+// Motif is a named sequence pattern to be searched for in a Genome.
+// A Motif is created from either an IUPAC string (NewMotif) or a
+// regular expression (NewRegexpMotif). In both cases matching ignores
+// case so soft-masked sequence is searched like any other sequence.
+type Motif struct {
+	Name     string
+	Pattern  string
+	IsRegexp bool
+
+	// re matches the motif read 5'->3'. Minus strand sites are found
+	// by running it against the reverse complement of the sequence.
+	re *regexp.Regexp
+
+	// palindrome is true for IUPAC motifs that are their own reverse
+	// complement, e.g. GAATTC, so that each site is reported once.
+	palindrome bool
+}
+
+// NewMotif creates a Motif from a string of IUPAC nucleotide codes,
+// for example NGG for a Cas9 PAM or TATAWAWR for a TATA box.
+func NewMotif(name, pattern string) (*Motif, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("genome.NewMotif: pattern for motif %s is empty", name)
+	}
+	var b strings.Builder
+	b.WriteString("(?i)")
+	up := strings.ToUpper(pattern)
+	for i := 0; i < len(up); i++ {
+		class, ok := iupacClasses[up[i]]
+		if !ok {
+			return nil, fmt.Errorf("genome.NewMotif: motif %s has non-IUPAC character %q at position %d", name, pattern[i], i+1)
+		}
+		b.WriteString(class)
+	}
+	m := &Motif{Name: name, Pattern: pattern}
+	m.re = regexp.MustCompile(b.String())
+	m.palindrome = string(reverseComplement([]byte(up))) == up
+	return m, nil
+}
+
+// NewRegexpMotif creates a Motif from a Go regular expression. The
+// expression is matched against each strand read 5'->3' so, for
+// example, GC.{4}GC finds sites on either strand.
+func NewRegexpMotif(name, expr string) (*Motif, error) {
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("genome.NewRegexpMotif: error compiling motif %s: %w", name, err)
+	}
+	return &Motif{Name: name, Pattern: expr, IsRegexp: true, re: re}, nil
+}
+
+// FindMotif returns a gff3.Feature for every site in the FastaRec that
+// matches the Motif on either strand. Overlapping sites are all
+// reported, so NGG finds two sites in GGGG on the plus strand.
+// Palindromic IUPAC motifs are only searched once and the Feature
+// Strand is set to ".".
+//
+// Each Feature has the FastaRec Name as SeqId, Type sequence_motif and
+// Attributes Name (the Motif name), Pattern and Sequence (the matched
+// bases read 5'->3' on the matching strand).
+func (r *FastaRec) FindMotif(m *Motif) []*gff3.Feature {
+	var feats []*gff3.Feature
+	seq := []byte(r.Sequence)
+	l := len(seq)
+
+	strand := "+"
+	if m.palindrome {
+		strand = "."
+	}
+	for _, loc := range overlappingMatches(m.re, seq) {
+		feats = append(feats, motifFeature(r.Name, m, loc[0]+1, loc[1], strand,
+			string(seq[loc[0]:loc[1]])))
+	}
+
+	if m.palindrome {
+		return feats
+	}
+
+	// Search the reverse complement and translate the coordinates
+	// back to the plus strand.
+	rc := reverseComplement(seq)
+	for _, loc := range overlappingMatches(m.re, rc) {
+		feats = append(feats, motifFeature(r.Name, m, l-loc[1]+1, l-loc[0], "-",
+			string(rc[loc[0]:loc[1]])))
+	}
+
+	return feats
+}
+
+// FindMotifs searches every sequence in the Genome for every Motif and
+// returns the hits as a sorted gff3.Features which can be added to a
+// Gff3 for writing or compared against an annotation.
+func (g *Genome) FindMotifs(motifs []*Motif) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(s *FastaRec) error {
+		for _, m := range motifs {
+			fs.AddFeatures(s.FindMotif(m)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.FindMotifs: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
+
+// overlappingMatches is like regexp.FindAllIndex except that it
+// restarts the search one base after the start of each match rather
+// than at the end of the match so overlapping matches are found.
+// Empty matches are ignored.
+func overlappingMatches(re *regexp.Regexp, b []byte) [][]int {
+	var locs [][]int
+	for i := 0; i < len(b); {
+		loc := re.FindIndex(b[i:])
+		if loc == nil {
+			break
+		}
+		if loc[1] > loc[0] {
+			locs = append(locs, []int{i + loc[0], i + loc[1]})
+		}
+		i += loc[0] + 1
+	}
+	return locs
+}
+
+func motifFeature(seqId string, m *Motif, start, end int, strand, bases string) *gff3.Feature {
+	f := gff3.NewFeature()
+	f.SeqId = seqId
+	f.Source = `grz-motif`
+	f.Type = `sequence_motif`
+	f.Start = start
+	f.End = end
+	f.Strand = strand
+	f.Attributes[`Name`] = gff3.EscapeAttribute(m.Name)
+	f.Attributes[`Pattern`] = gff3.EscapeAttribute(m.Pattern)
+	f.Attributes[`Sequence`] = bases
+	return f
+}
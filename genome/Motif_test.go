@@ -0,0 +1,87 @@
+package genome
+
+import (
+	"testing"
+)
+
+func TestFindMotif(t *testing.T) {
+	r := NewFastaRec(">chrT1")
+	r.Sequence = `aaGAATTCaaGGGtttCCAatNGG`
+
+	// EcoRI is palindromic so should be reported once
+	eco, err := NewMotif("EcoRI", "GAATTC")
+	if err != nil {
+		t.Fatalf(`NewMotif failed: %v`, err)
+	}
+	fs := r.FindMotif(eco)
+	if len(fs) != 1 {
+		t.Fatalf(`EcoRI should have 1 site but has %d`, len(fs))
+	}
+	if fs[0].Start != 3 || fs[0].End != 8 || fs[0].Strand != "." {
+		t.Fatalf(`EcoRI site incorrect: %d-%d(%s)`, fs[0].Start, fs[0].End, fs[0].Strand)
+	}
+
+	// NGG has 2 overlapping plus strand sites in aGGG, 1 minus strand
+	// site from CCA and no site at NGG because N is not a base.
+	pam, err := NewMotif("PAM", "NGG")
+	if err != nil {
+		t.Fatalf(`NewMotif failed: %v`, err)
+	}
+	fs = r.FindMotif(pam)
+	if len(fs) != 3 {
+		t.Fatalf(`PAM should have 3 sites but has %d`, len(fs))
+	}
+	tests := []struct {
+		start  int
+		end    int
+		strand string
+		seq    string
+	}{
+		{10, 12, "+", "aGG"},
+		{11, 13, "+", "GGG"},
+		{17, 19, "-", "TGG"},
+	}
+	for i, tc := range tests {
+		f := fs[i]
+		if f.Start != tc.start || f.End != tc.end || f.Strand != tc.strand ||
+			f.Attributes[`Sequence`] != tc.seq {
+			t.Fatalf(`PAM site %d should be %d-%d(%s) %s but is %d-%d(%s) %s`, i,
+				tc.start, tc.end, tc.strand, tc.seq,
+				f.Start, f.End, f.Strand, f.Attributes[`Sequence`])
+		}
+	}
+
+	_, err = NewMotif("bad", "NGX")
+	if err == nil {
+		t.Fatalf(`NewMotif should have failed on non-IUPAC pattern`)
+	}
+}
+
+func TestFindMotifs(t *testing.T) {
+	g := NewGenome("testing")
+	r1 := NewFastaRec(">chrT1")
+	r1.Sequence = `GCaaaaGCttGCAT`
+	r2 := NewFastaRec(">chrT2")
+	r2.Sequence = `ATGCttttGC`
+	g.Sequences = append(g.Sequences, r1, r2)
+
+	m, err := NewRegexpMotif("gc4gc", "GC.{4}GC")
+	if err != nil {
+		t.Fatalf(`NewRegexpMotif failed: %v`, err)
+	}
+	fs, err := g.FindMotifs([]*Motif{m})
+	if err != nil {
+		t.Fatalf(`FindMotifs failed: %v`, err)
+	}
+	if fs.Count() != 4 {
+		t.Fatalf(`gc4gc should have 4 sites but has %d`, fs.Count())
+	}
+	if fs.Features[0].SeqId != "chrT1" || fs.Features[3].SeqId != "chrT2" {
+		t.Fatalf(`sites not sorted by SeqId: %s %s`, fs.Features[0].SeqId, fs.Features[3].SeqId)
+	}
+	e1 := `GC.{4}GC`
+	g1 := fs.Features[0].Attributes[`Pattern`]
+	if e1 != g1 {
+		t.Fatalf(`Pattern attribute should be %s but is %s`, e1, g1)
+	}
+}
@@ -0,0 +1,190 @@
+package genome
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// OrfStart selects which codons may begin an open reading frame.
+type OrfStart int
+
+const (
+	OrfStartATG         OrfStart = iota // only ATG
+	OrfStartAlternative                 // any start codon in the table
+	OrfStartAny                         // stop-to-stop, no start needed
+)
+
+// OrfOptions controls FindOrfs.
+//
+// Table is the NCBI translation table Id used to recognise start and
+// stop codons. MinLength is the minimum ORF length in bases, including
+// the stop codon. If Partial is true, ORFs that run off the end of the
+// sequence without a stop codon are also reported. Type is the GFF3
+// Type given to each Feature, usually ORF or CDS.
+type OrfOptions struct {
+	Table     int
+	MinLength int
+	Start     OrfStart
+	Partial   bool
+	Type      string
+}
+
+// NewOrfOptions returns OrfOptions that find ORFs of at least 75 bases
+// that begin with ATG and end with a stop in the standard code, which
+// matches the NCBI ORFfinder defaults.
+func NewOrfOptions() *OrfOptions {
+	return &OrfOptions{
+		Table:     1,
+		MinLength: 75,
+		Start:     OrfStartATG,
+		Type:      `ORF`,
+	}
+}
+
+// FindOrfs searches all six reading frames of the Sequence for open
+// reading frames and returns one gff3.Feature per ORF sorted by Start.
+// If opts is nil, the defaults from NewOrfOptions are used. Only the
+// longest ORF ending at each stop codon is reported so ORFs nested in
+// the same frame are not.
+//
+// Start and End include the start and stop codons. Because every ORF
+// begins on a codon boundary, Phase is always 0 and the reading frame
+// (+1, +2, +3, -1, -2, -3 counted from the 5' end of each strand) is
+// in the Frame attribute. Each Feature is given an ID so that the
+// Features can be loaded into a gff3.Tree. ORFs without a stop codon
+// have the attribute partial=true.
+func (s *Sequence) FindOrfs(opts *OrfOptions) ([]*gff3.Feature, error) {
+	if opts == nil {
+		opts = NewOrfOptions()
+	}
+	gc, err := GeneticCodeById(opts.Table)
+	if err != nil {
+		return nil, fmt.Errorf("genome.Sequence.FindOrfs: %w", err)
+	}
+
+	var feats []*gff3.Feature
+	plus := []byte(s.Sequence)
+	minus := reverseComplement(plus)
+	l := len(plus)
+	for frame := 0; frame < 3; frame++ {
+		for _, o := range orfsInFrame(plus, frame, gc, opts) {
+			f := orfFeature(s.Name, o[0]+1, o[1], `+`, fmt.Sprintf("+%d", frame+1), opts)
+			if o[2] == 1 {
+				f.Attributes[`partial`] = `true`
+			}
+			feats = append(feats, f)
+		}
+		for _, o := range orfsInFrame(minus, frame, gc, opts) {
+			f := orfFeature(s.Name, l-o[1]+1, l-o[0], `-`, fmt.Sprintf("-%d", frame+1), opts)
+			if o[2] == 1 {
+				f.Attributes[`partial`] = `true`
+			}
+			feats = append(feats, f)
+		}
+	}
+
+	sort.SliceStable(feats, func(i, j int) bool {
+		if feats[i].Start != feats[j].Start {
+			return feats[i].Start < feats[j].Start
+		}
+		return feats[i].End < feats[j].End
+	})
+	for i, f := range feats {
+		f.Attributes[`ID`] = gff3.EscapeAttribute(fmt.Sprintf("%s_orf%d", s.Name, i+1))
+	}
+
+	return feats, nil
+}
+
+// FindOrfs finds the open reading frames in the FastaRec. The
+// Features use the FastaRec Name as SeqId. See Sequence.FindOrfs.
+func (r *FastaRec) FindOrfs(opts *OrfOptions) ([]*gff3.Feature, error) {
+	s := &Sequence{Name: r.Name, Sequence: r.Sequence}
+	feats, err := s.FindOrfs(opts)
+	if err != nil {
+		return nil, fmt.Errorf("genome.FastaRec.FindOrfs: %w", err)
+	}
+	return feats, nil
+}
+
+// FindOrfs finds the open reading frames in every sequence of the
+// Genome and returns them as a sorted gff3.Features.
+func (g *Genome) FindOrfs(opts *OrfOptions) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(r *FastaRec) error {
+		feats, err := r.FindOrfs(opts)
+		if err != nil {
+			return err
+		}
+		fs.AddFeatures(feats...)
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.FindOrfs: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
+
+// orfsInFrame returns the ORFs in one frame of seq as triples of the
+// 0-based half-open start and end plus a flag which is 1 if the ORF
+// has no stop codon.
+func orfsInFrame(seq []byte, frame int, gc *GeneticCode, opts *OrfOptions) [][3]int {
+	var orfs [][3]int
+	start := -1
+	if opts.Start == OrfStartAny {
+		start = frame
+	}
+	i := frame
+	for ; i+3 <= len(seq); i += 3 {
+		codon := string(seq[i : i+3])
+		if gc.IsStop(codon) {
+			if start >= 0 && i+3-start >= opts.MinLength {
+				orfs = append(orfs, [3]int{start, i + 3, 0})
+			}
+			start = -1
+			if opts.Start == OrfStartAny {
+				start = i + 3
+			}
+			continue
+		}
+		if start < 0 && isOrfStart(codon, gc, opts.Start) {
+			start = i
+		}
+	}
+	if opts.Partial && start >= 0 && i > start && i-start >= opts.MinLength {
+		orfs = append(orfs, [3]int{start, i, 1})
+	}
+	return orfs
+}
+
+func isOrfStart(codon string, gc *GeneticCode, mode OrfStart) bool {
+	switch mode {
+	case OrfStartATG:
+		return toUpper(codon[0]) == 'A' && iupacMatch('T', codon[1]) &&
+			toUpper(codon[2]) == 'G'
+	case OrfStartAlternative:
+		return gc.IsStart(codon)
+	}
+	return false
+}
+
+func orfFeature(seqId string, start, end int, strand, frame string, opts *OrfOptions) *gff3.Feature {
+	f := gff3.NewFeature()
+	f.SeqId = seqId
+	f.Source = `grz-orf`
+	f.Type = opts.Type
+	if f.Type == "" {
+		f.Type = `ORF`
+	}
+	f.Start = start
+	f.End = end
+	f.Strand = strand
+	f.Phase = `0`
+	f.Attributes[`Frame`] = frame
+	return f
+}
@@ -0,0 +1,93 @@
+package genome
+
+import (
+	"testing"
+)
+
+func TestFindOrfs(t *testing.T) {
+	// Plus strand ORF ATG AAA CCC TAA at 3-14 (frame +3) and a minus
+	// strand ORF at 17-28 whose reverse complement is ATG GGG TTT TGA.
+	s := &Sequence{Name: "chrT",
+		Sequence: `ccATGAAACCCTAAggTCAAAACCCCATgg`}
+
+	opts := NewOrfOptions()
+	opts.MinLength = 12
+	feats, err := s.FindOrfs(opts)
+	if err != nil {
+		t.Fatalf(`FindOrfs failed: %v`, err)
+	}
+	if len(feats) != 2 {
+		t.Fatalf(`FindOrfs should have found 2 ORFs but found %d`, len(feats))
+	}
+
+	tests := []struct {
+		start  int
+		end    int
+		strand string
+		frame  string
+	}{
+		{3, 14, "+", "+3"},
+		{17, 28, "-", "-3"},
+	}
+	for i, tc := range tests {
+		f := feats[i]
+		if f.Start != tc.start || f.End != tc.end || f.Strand != tc.strand ||
+			f.Attributes[`Frame`] != tc.frame || f.Phase != `0` || f.Type != `ORF` {
+			t.Fatalf(`ORF %d should be %d-%d(%s) frame %s but is %s`, i,
+				tc.start, tc.end, tc.strand, tc.frame, f.String())
+		}
+	}
+	if feats[1].Attributes[`ID`] != `chrT_orf2` {
+		t.Fatalf(`ORF 1 ID should be chrT_orf2 but is %s`, feats[1].Attributes[`ID`])
+	}
+
+	// Raising MinLength removes both ORFs
+	opts.MinLength = 15
+	feats, err = s.FindOrfs(opts)
+	if err != nil {
+		t.Fatalf(`FindOrfs failed: %v`, err)
+	}
+	if len(feats) != 0 {
+		t.Fatalf(`FindOrfs should have found 0 ORFs but found %d`, len(feats))
+	}
+
+	// Alternative starts and partial ORFs
+	s2 := &Sequence{Name: "chrT2", Sequence: `TTGAAACCCGGG`}
+	opts = NewOrfOptions()
+	opts.MinLength = 12
+	opts.Table = 11
+	opts.Start = OrfStartAlternative
+	opts.Partial = true
+	opts.Type = `CDS`
+	feats, err = s2.FindOrfs(opts)
+	if err != nil {
+		t.Fatalf(`FindOrfs failed: %v`, err)
+	}
+	if len(feats) != 1 {
+		t.Fatalf(`FindOrfs should have found 1 ORF but found %d`, len(feats))
+	}
+	if feats[0].Start != 1 || feats[0].End != 12 ||
+		feats[0].Attributes[`partial`] != `true` || feats[0].Type != `CDS` {
+		t.Fatalf(`partial ORF incorrect: %s`, feats[0].String())
+	}
+}
+
+func TestGenomeFindOrfs(t *testing.T) {
+	g := NewGenome("testing")
+	r := NewFastaRec(">chrT1 | test")
+	r.Sequence = `ccATGAAACCCTAAgg`
+	g.Sequences = append(g.Sequences, r)
+
+	opts := NewOrfOptions()
+	opts.MinLength = 12
+	fs, err := g.FindOrfs(opts)
+	if err != nil {
+		t.Fatalf(`FindOrfs failed: %v`, err)
+	}
+	if fs.Count() != 1 {
+		t.Fatalf(`FindOrfs should have found 1 ORF but found %d`, fs.Count())
+	}
+	if fs.Features[0].SeqId != `chrT1` {
+		t.Fatalf(`ORF SeqId should be chrT1 but is %s`, fs.Features[0].SeqId)
+	}
+}
@@ -0,0 +1,215 @@
+package genome
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PrimerPair is a named pair of PCR primers. Both primers are written
+// 5'->3' as they would be ordered from a supplier so the Reverse
+// primer binds to the plus strand of the template as its reverse
+// complement. IUPAC degenerate codes are allowed in both primers.
+type PrimerPair struct {
+	Name    string
+	Forward string
+	Reverse string
+}
+
+// PcrOptions controls how tolerant InSilicoPcr is of mismatches between
+// primers and template and which products are reported.
+//
+// Mismatches near the 3' end of a primer are far more damaging to
+// extension than mismatches near the 5' end so they are counted
+// separately. ThreePrimeLength sets how many bases at the 3' end of
+// each primer are considered the 3' region and ThreePrimeMismatches
+// sets how many mismatches are allowed in that region.
+// MaxMismatches is the limit on mismatches across the whole primer,
+// including the 3' region.
+type PcrOptions struct {
+	MaxMismatches        int
+	ThreePrimeLength     int
+	ThreePrimeMismatches int
+	MinProductLength     int
+	MaxProductLength     int
+}
+
+// NewPcrOptions returns PcrOptions with defaults that suit checking a
+// typical amplicon panel for off-target products: up to 2 mismatches
+// per primer but none in the last 5 bases, and products of 1 to 3000
+// bases.
+func NewPcrOptions() *PcrOptions {
+	return &PcrOptions{
+		MaxMismatches:        2,
+		ThreePrimeLength:     5,
+		ThreePrimeMismatches: 0,
+		MinProductLength:     1,
+		MaxProductLength:     3000,
+	}
+}
+
+// Amplicon is a product predicted by InSilicoPcr. Start and End are
+// 1-based closed coordinates on the plus strand of the sequence and
+// include both primers. Strand is "+" if the Forward primer binds the
+// plus strand and "-" if it binds the minus strand. Sequence is always
+// given in the orientation of the Forward primer so it starts with the
+// Forward primer binding site and ends with the reverse complement of
+// the Reverse primer binding site.
+type Amplicon struct {
+	PrimerPair        *PrimerPair
+	SeqName           string
+	Start             int
+	End               int
+	Strand            string
+	Length            int
+	Sequence          string
+	ForwardMismatches int
+	ReverseMismatches int
+}
+
+// primerSite is a location where a primer binds. Start is the 0-based
+// offset of the binding site on the plus strand.
+type primerSite struct {
+	Start      int
+	Mismatches int
+}
+
+// InSilicoPcr searches both strands of every sequence in the Genome
+// for products that could be amplified by each of the supplied primer
+// pairs. If opts is nil, the defaults from NewPcrOptions are used.
+// Amplicons are returned in the order of the primer pairs and, within
+// each pair, sorted by sequence and position.
+//
+// The search is an exhaustive scan rather than a Seed lookup because a
+// Seed only indexes the plus strand and cannot see degenerate primers
+// or mismatches at the seeded positions.
+func (g *Genome) InSilicoPcr(pairs []*PrimerPair, opts *PcrOptions) ([]*Amplicon, error) {
+	if opts == nil {
+		opts = NewPcrOptions()
+	}
+
+	var amps []*Amplicon
+	for _, p := range pairs {
+		if p.Forward == "" || p.Reverse == "" {
+			return amps, fmt.Errorf("genome.Genome.InSilicoPcr: primer pair %s must have both primers", p.Name)
+		}
+		fwd := []byte(p.Forward)
+		rev := []byte(p.Reverse)
+		var pamps []*Amplicon
+		err := g.eachSequence(func(s *FastaRec) error {
+			seq := []byte(s.Sequence)
+			fwdPlus := primerSites(seq, fwd, opts, false)
+			fwdMinus := primerSites(seq, fwd, opts, true)
+			revPlus := primerSites(seq, rev, opts, false)
+			revMinus := primerSites(seq, rev, opts, true)
+
+			// Forward primer on the plus strand with Reverse primer
+			// downstream on the minus strand.
+			for _, f := range fwdPlus {
+				for _, r := range revMinus {
+					start, end := f.Start, r.Start+len(rev)
+					if r.Start < f.Start || !productFits(start, end, opts) {
+						continue
+					}
+					pamps = append(pamps, &Amplicon{
+						PrimerPair:        p,
+						SeqName:           s.Name,
+						Start:             start + 1,
+						End:               end,
+						Strand:            "+",
+						Length:            end - start,
+						Sequence:          string(seq[start:end]),
+						ForwardMismatches: f.Mismatches,
+						ReverseMismatches: r.Mismatches,
+					})
+				}
+			}
+
+			// Reverse primer on the plus strand with Forward primer
+			// downstream on the minus strand.
+			for _, r := range revPlus {
+				for _, f := range fwdMinus {
+					start, end := r.Start, f.Start+len(fwd)
+					if f.Start < r.Start || !productFits(start, end, opts) {
+						continue
+					}
+					pamps = append(pamps, &Amplicon{
+						PrimerPair:        p,
+						SeqName:           s.Name,
+						Start:             start + 1,
+						End:               end,
+						Strand:            "-",
+						Length:            end - start,
+						Sequence:          string(reverseComplement(seq[start:end])),
+						ForwardMismatches: f.Mismatches,
+						ReverseMismatches: r.Mismatches,
+					})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return amps, fmt.Errorf("genome.Genome.InSilicoPcr: %w", err)
+		}
+
+		sort.SliceStable(pamps, func(i, j int) bool {
+			if pamps[i].SeqName != pamps[j].SeqName {
+				return pamps[i].SeqName < pamps[j].SeqName
+			}
+			return pamps[i].Start < pamps[j].Start
+		})
+		amps = append(amps, pamps...)
+	}
+
+	return amps, nil
+}
+
+func productFits(start, end int, opts *PcrOptions) bool {
+	l := end - start
+	return l >= opts.MinProductLength && l <= opts.MaxProductLength
+}
+
+// primerSites returns every site where primer binds seq. If minus is
+// true, the primer is matched against the minus strand, i.e. its
+// reverse complement is matched against the plus strand.
+func primerSites(seq, primer []byte, opts *PcrOptions, minus bool) []primerSite {
+	var sites []primerSite
+	plen := len(primer)
+	for i := 0; i+plen <= len(seq); i++ {
+		if mm, ok := primerMismatches(seq[i:i+plen], primer, opts, minus); ok {
+			sites = append(sites, primerSite{Start: i, Mismatches: mm})
+		}
+	}
+	return sites
+}
+
+// primerMismatches counts mismatches between primer and a window of
+// the plus strand. It gives up as soon as either mismatch limit from
+// opts is exceeded, in which case the bool is false.
+func primerMismatches(window, primer []byte, opts *PcrOptions, minus bool) (int, bool) {
+	plen := len(primer)
+	var mm, mm3 int
+	// Walk from the 3' end of the primer because that is where
+	// mismatches are least tolerated so we can bail out early.
+	for k := plen - 1; k >= 0; k-- {
+		var base byte
+		if minus {
+			base = complements[toUpper(window[plen-1-k])]
+		} else {
+			base = window[k]
+		}
+		if iupacMatch(primer[k], base) {
+			continue
+		}
+		mm++
+		if plen-k <= opts.ThreePrimeLength {
+			mm3++
+			if mm3 > opts.ThreePrimeMismatches {
+				return mm, false
+			}
+		}
+		if mm > opts.MaxMismatches {
+			return mm, false
+		}
+	}
+	return mm, true
+}
@@ -0,0 +1,89 @@
+package genome
+
+import (
+	"testing"
+)
+
+func TestInSilicoPcr(t *testing.T) {
+	g := NewGenome("testing")
+	r1 := NewFastaRec(">chrT1")
+	// Forward primer ACGTACGTAA at 1-10, reverse primer TTGGCCAAGT
+	// binds as its reverse complement ACTTGGCCAA at 31-40.
+	r1.Sequence = `ACGTACGTAAccccccccccccccccccccACTTGGCCAAgggg`
+	r2 := NewFastaRec(">chrT2")
+	// The same product on the minus strand.
+	r2.Sequence = `ccccTTGGCCAAGTggggggggggggggggggggTTACGTACGT`
+	g.Sequences = append(g.Sequences, r1, r2)
+
+	pp := &PrimerPair{Name: "pp1",
+		Forward: "ACGTACGTAA",
+		Reverse: "TTGGCCAAGT"}
+
+	amps, err := g.InSilicoPcr([]*PrimerPair{pp}, nil)
+	if err != nil {
+		t.Fatalf(`InSilicoPcr failed: %v`, err)
+	}
+	if len(amps) != 2 {
+		t.Fatalf(`InSilicoPcr should have found 2 amplicons but found %d`, len(amps))
+	}
+
+	e1 := `ACGTACGTAAccccccccccccccccccccACTTGGCCAA`
+	for i, a := range amps {
+		if a.Length != 40 {
+			t.Fatalf(`amplicon %d Length should be 40 but is %d`, i, a.Length)
+		}
+		if a.Sequence != e1 {
+			t.Fatalf(`amplicon %d Sequence should be %s but is %s`, i, e1, a.Sequence)
+		}
+	}
+	if amps[0].SeqName != "chrT1" || amps[0].Strand != "+" ||
+		amps[0].Start != 1 || amps[0].End != 40 {
+		t.Fatalf(`amplicon 0 location incorrect: %s:%d-%d(%s)`,
+			amps[0].SeqName, amps[0].Start, amps[0].End, amps[0].Strand)
+	}
+	if amps[1].SeqName != "chrT2" || amps[1].Strand != "-" ||
+		amps[1].Start != 5 || amps[1].End != 44 {
+		t.Fatalf(`amplicon 1 location incorrect: %s:%d-%d(%s)`,
+			amps[1].SeqName, amps[1].Start, amps[1].End, amps[1].Strand)
+	}
+
+	// A mismatch in the 5' half is tolerated but one at the 3' end is not.
+	pp5 := &PrimerPair{Name: "pp5", Forward: "TCGTACGTAA", Reverse: "TTGGCCAAGT"}
+	pp3 := &PrimerPair{Name: "pp3", Forward: "ACGTACGTAT", Reverse: "TTGGCCAAGT"}
+	amps, err = g.InSilicoPcr([]*PrimerPair{pp5, pp3}, nil)
+	if err != nil {
+		t.Fatalf(`InSilicoPcr failed: %v`, err)
+	}
+	if len(amps) != 2 {
+		t.Fatalf(`InSilicoPcr should have found 2 amplicons but found %d`, len(amps))
+	}
+	for i, a := range amps {
+		if a.PrimerPair != pp5 || a.ForwardMismatches != 1 {
+			t.Fatalf(`amplicon %d should be from pp5 with 1 mismatch: %s %d`,
+				i, a.PrimerPair.Name, a.ForwardMismatches)
+		}
+	}
+
+	// Degenerate primers
+	ppd := &PrimerPair{Name: "ppd", Forward: "ACGNACGTRA", Reverse: "TTGGCCAAGY"}
+	opts := NewPcrOptions()
+	opts.MaxMismatches = 0
+	amps, err = g.InSilicoPcr([]*PrimerPair{ppd}, opts)
+	if err != nil {
+		t.Fatalf(`InSilicoPcr failed: %v`, err)
+	}
+	if len(amps) != 2 {
+		t.Fatalf(`InSilicoPcr with degenerate primers should have found 2 amplicons but found %d`, len(amps))
+	}
+
+	// Products outside the size limits are not reported
+	opts = NewPcrOptions()
+	opts.MaxProductLength = 39
+	amps, err = g.InSilicoPcr([]*PrimerPair{pp}, opts)
+	if err != nil {
+		t.Fatalf(`InSilicoPcr failed: %v`, err)
+	}
+	if len(amps) != 0 {
+		t.Fatalf(`InSilicoPcr should have found 0 amplicons but found %d`, len(amps))
+	}
+}
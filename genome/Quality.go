@@ -0,0 +1,241 @@
+package genome
+
+import (
+	"fmt"
+	"math"
+)
+
+// QualityEncoding is the scheme used to encode base qualities as ASCII
+// characters in a FASTQ file.
+type QualityEncoding int
+
+const (
+	UnknownEncoding QualityEncoding = iota
+	// Phred33 (Sanger, Illumina 1.8+) encodes Phred scores 0-93 as
+	// ! to ~.
+	Phred33
+	// Phred64 (Illumina 1.3-1.7) encodes Phred scores 0-62 as @ to ~.
+	Phred64
+	// Solexa64 (Solexa, Illumina 1.0) encodes Solexa scores -5 to 62
+	// as ; to ~.
+	Solexa64
+)
+
+func (e QualityEncoding) String() string {
+	switch e {
+	case Phred33:
+		return `Phred+33`
+	case Phred64:
+		return `Phred+64`
+	case Solexa64:
+		return `Solexa+64`
+	}
+	return `unknown`
+}
+
+// Offset returns the ASCII value of a score of 0.
+func (e QualityEncoding) Offset() int {
+	switch e {
+	case Phred33:
+		return 33
+	case Phred64, Solexa64:
+		return 64
+	}
+	return 0
+}
+
+// scoreRange returns the lowest and highest score the encoding allows.
+func (e QualityEncoding) scoreRange() (int, int) {
+	switch e {
+	case Phred33:
+		return 0, 93
+	case Phred64:
+		return 0, 62
+	case Solexa64:
+		return -5, 62
+	}
+	return 0, 0
+}
+
+// DetectQualityEncoding guesses the quality encoding from the range of
+// quality characters in a sample of records. Any character below ; can
+// only be Phred+33 and any character from ; to ? can only be Solexa.
+// If every character is @ or above, the sample is Phred+64 if it has
+// characters above K (Phred+33 Q42, higher than any Illumina
+// instrument reports) and otherwise Phred+33 because a sample of
+// uniformly high quality Phred+33 reads is more likely than a sample
+// of Phred+64 reads with no quality below Q0-Q11. An error is returned
+// if the sample has no qualities.
+func DetectQualityEncoding(recs []*FastqRec) (QualityEncoding, error) {
+	min, max := byte(255), byte(0)
+	for _, r := range recs {
+		for _, q := range r.Qualities {
+			if q < min {
+				min = q
+			}
+			if q > max {
+				max = q
+			}
+		}
+	}
+	switch {
+	case max == 0:
+		return UnknownEncoding, fmt.Errorf("genome.DetectQualityEncoding: no qualities in %d records", len(recs))
+	case min < '!' || max > '~':
+		return UnknownEncoding, fmt.Errorf("genome.DetectQualityEncoding: quality characters %q to %q are outside the printable range", min, max)
+	case min < ';':
+		return Phred33, nil
+	case min < '@':
+		return Solexa64, nil
+	case max > 'K':
+		return Phred64, nil
+	}
+	return Phred33, nil
+}
+
+// ConvertQualities re-encodes the Qualities of the record from one
+// encoding to another. Solexa scores are converted to and from Phred
+// scores with the usual log-odds formula and rounded. Scores that do
+// not fit in the new encoding are clamped to its range. It is an error
+// for a quality to be outside the range of the from encoding.
+func (r *FastqRec) ConvertQualities(from, to QualityEncoding) error {
+	if from == UnknownEncoding || to == UnknownEncoding {
+		return fmt.Errorf("genome.FastqRec.ConvertQualities: cannot convert %s to %s", from, to)
+	}
+	if from == to {
+		return nil
+	}
+	fmin, fmax := from.scoreRange()
+	tmin, tmax := to.scoreRange()
+	quals := make([]byte, len(r.Qualities))
+	for i, c := range r.Qualities {
+		q := int(c) - from.Offset()
+		if q < fmin || q > fmax {
+			return fmt.Errorf("genome.FastqRec.ConvertQualities: quality %q at position %d of read %s is not %s", c, i+1, r.Id, from)
+		}
+		if from == Solexa64 {
+			q = solexaToPhred(q)
+		}
+		if to == Solexa64 {
+			q = phredToSolexa(q)
+		}
+		if q < tmin {
+			q = tmin
+		}
+		if q > tmax {
+			q = tmax
+		}
+		quals[i] = byte(q + to.Offset())
+	}
+	r.Qualities = quals
+	return nil
+}
+
+func solexaToPhred(q int) int {
+	return int(math.Round(10 * math.Log10(math.Pow(10, float64(q)/10)+1)))
+}
+
+func phredToSolexa(q int) int {
+	if q < 1 {
+		return -5
+	}
+	s := int(math.Round(10 * math.Log10(math.Pow(10, float64(q)/10)-1)))
+	if s < -5 {
+		return -5
+	}
+	return s
+}
+
+// The quality accessors below assume Phred+33 qualities. Records read
+// from files with another encoding should be converted first with
+// ConvertQualities.
+
+// phredErrorProb caches the error probability for every Phred+33
+// score.
+var phredErrorProb = func() [94]float64 {
+	var p [94]float64
+	for q := range p {
+		p[q] = math.Pow(10, -float64(q)/10)
+	}
+	return p
+}()
+
+// ErrorProbability returns the probability that a base with Phred
+// score q is wrong.
+func ErrorProbability(q int) float64 {
+	if q >= 0 && q < len(phredErrorProb) {
+		return phredErrorProb[q]
+	}
+	return math.Pow(10, -float64(q)/10)
+}
+
+// Quality returns the Phred score of the base at 0-based position i.
+func (r *FastqRec) Quality(i int) int {
+	return int(r.Qualities[i]) - 33
+}
+
+// QualityScores returns the Phred scores of all bases.
+func (r *FastqRec) QualityScores() []int {
+	scores := make([]int, len(r.Qualities))
+	for i, c := range r.Qualities {
+		scores[i] = int(c) - 33
+	}
+	return scores
+}
+
+// MeanQuality returns the arithmetic mean of the Phred scores or 0 for
+// a record with no bases. Because Phred scores are logarithmic this
+// overstates the quality of reads with a few bad bases;
+// ExpectedErrors is often the better filter.
+func (r *FastqRec) MeanQuality() float64 {
+	if len(r.Qualities) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, c := range r.Qualities {
+		sum += int(c) - 33
+	}
+	return float64(sum) / float64(len(r.Qualities))
+}
+
+// ExpectedErrors returns the expected number of wrong bases in the
+// read, the sum of the error probabilities of its bases.
+func (r *FastqRec) ExpectedErrors() float64 {
+	ee := 0.0
+	for _, c := range r.Qualities {
+		ee += ErrorProbability(int(c) - 33)
+	}
+	return ee
+}
+
+// BinQuality maps a Phred score to the 8-level binning used by
+// Illumina instruments: scores below 2 are unchanged, 2-9 become 6,
+// 10-19 become 15, 20-24 become 22, 25-29 become 27, 30-34 become 33,
+// 35-39 become 37 and 40 and above become 40.
+func BinQuality(q int) int {
+	switch {
+	case q < 2:
+		return q
+	case q < 10:
+		return 6
+	case q < 20:
+		return 15
+	case q < 25:
+		return 22
+	case q < 30:
+		return 27
+	case q < 35:
+		return 33
+	case q < 40:
+		return 37
+	}
+	return 40
+}
+
+// BinQualities replaces the Phred+33 Qualities of the record with their
+// BinQuality bins, which makes FASTQ files compress much better.
+func (r *FastqRec) BinQualities() {
+	for i, c := range r.Qualities {
+		r.Qualities[i] = byte(BinQuality(int(c)-33) + 33)
+	}
+}
@@ -0,0 +1,95 @@
+package genome
+
+import (
+	"math"
+	"testing"
+)
+
+func qualRec(quals string) *FastqRec {
+	r := NewFastqRec()
+	r.Id = `q`
+	r.Qualities = []byte(quals)
+	r.Bases = make([]byte, len(quals))
+	for i := range r.Bases {
+		r.Bases[i] = 'A'
+	}
+	return r
+}
+
+func TestDetectQualityEncoding(t *testing.T) {
+	tests := []struct {
+		quals []string
+		want  QualityEncoding
+	}{
+		{[]string{`JJJJ`, `#,:FF`}, Phred33},
+		{[]string{`IIIIIIII`}, Phred33},
+		{[]string{`hhhhBB`, `ffff`}, Phred64},
+		{[]string{`hhh;@@`}, Solexa64},
+	}
+	for _, tt := range tests {
+		var recs []*FastqRec
+		for _, q := range tt.quals {
+			recs = append(recs, qualRec(q))
+		}
+		got, err := DetectQualityEncoding(recs)
+		if err != nil {
+			t.Fatalf(`DetectQualityEncoding(%v) failed: %v`, tt.quals, err)
+		}
+		if got != tt.want {
+			t.Fatalf(`DetectQualityEncoding(%v) should be %s but is %s`, tt.quals, tt.want, got)
+		}
+	}
+	if _, err := DetectQualityEncoding([]*FastqRec{qualRec(``)}); err == nil {
+		t.Fatalf(`DetectQualityEncoding with no qualities should fail`)
+	}
+}
+
+func TestConvertQualities(t *testing.T) {
+	tests := []struct {
+		from, to QualityEncoding
+		in, want string
+	}{
+		{Phred33, Phred64, `!+5?I`, `@JT^h`},
+		{Phred64, Phred33, `@JT^h`, `!+5?I`},
+		{Phred33, Phred64, `~`, `~`},      // Q93 clamped to Q62
+		{Solexa64, Phred33, `;@J`, `"$+`}, // -5->1, 0->3, 10->10
+		{Phred33, Solexa64, `!"+`, `;;J`}, // 0->-5, 1->-5, 10->10
+	}
+	for _, tt := range tests {
+		r := qualRec(tt.in)
+		if err := r.ConvertQualities(tt.from, tt.to); err != nil {
+			t.Fatalf(`ConvertQualities(%s) %s to %s failed: %v`, tt.in, tt.from, tt.to, err)
+		}
+		if string(r.Qualities) != tt.want {
+			t.Fatalf(`ConvertQualities(%s) %s to %s should be %s but is %s`, tt.in, tt.from, tt.to, tt.want, r.Qualities)
+		}
+	}
+	if err := qualRec(`5`).ConvertQualities(Phred64, Phred33); err == nil {
+		t.Fatalf(`ConvertQualities should fail for a quality outside Phred+64`)
+	}
+}
+
+func TestQualityMetrics(t *testing.T) {
+	r := qualRec(`+5?I`) // Q10, Q20, Q30, Q40
+	if r.Quality(1) != 20 {
+		t.Fatalf(`Quality(1) should be 20 but is %d`, r.Quality(1))
+	}
+	if r.MeanQuality() != 25 {
+		t.Fatalf(`MeanQuality should be 25 but is %f`, r.MeanQuality())
+	}
+	if ee := r.ExpectedErrors(); math.Abs(ee-0.1111) > 1e-9 {
+		t.Fatalf(`ExpectedErrors should be 0.1111 but is %f`, ee)
+	}
+	if p := ErrorProbability(30); math.Abs(p-0.001) > 1e-12 {
+		t.Fatalf(`ErrorProbability(30) should be 0.001 but is %g`, p)
+	}
+
+	r = qualRec(`!"#+5:?DIJ`)
+	r.BinQualities()
+	want := []int{0, 1, 6, 15, 22, 27, 33, 37, 40, 40}
+	for i, q := range r.QualityScores() {
+		if q != want[i] {
+			t.Fatalf(`binned quality %d should be %d but is %d`, i, want[i], q)
+		}
+	}
+}
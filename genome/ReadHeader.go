@@ -0,0 +1,162 @@
+package genome
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HeaderFormat is the sequencing platform convention used for a read
+// name.
+type HeaderFormat int
+
+const (
+	UnknownHeader HeaderFormat = iota
+	// CasavaHeader is the Illumina Casava 1.8+ format, e.g.
+	// A00123:8:H7KJ2DSXX:1:1101:10004:10019 1:N:0:ACGTACGT+TTGATCCA
+	CasavaHeader
+	// IlluminaHeader is the format used before Casava 1.8, e.g.
+	// HWUSI-EAS100R:6:73:941:1973#ACGT/1
+	IlluminaHeader
+	// MgiHeader is the MGI/BGI (DNBSEQ) format, e.g.
+	// V300012345L1C001R0010000001/1
+	MgiHeader
+)
+
+func (f HeaderFormat) String() string {
+	switch f {
+	case CasavaHeader:
+		return `Casava`
+	case IlluminaHeader:
+		return `Illumina`
+	case MgiHeader:
+		return `MGI`
+	}
+	return `unknown`
+}
+
+// ReadHeader holds the fields of a parsed read name. Fields that the
+// format does not have are left at their zero value; for example only
+// Casava headers have a Run, Flowcell and Filtered flag and only MGI
+// headers have a Column, Row and Number (the position of the read in
+// the field of view). Read is 0 if the header does not say which read
+// of a pair it is.
+type ReadHeader struct {
+	Format     HeaderFormat
+	Instrument string
+	Run        int
+	Flowcell   string
+	Lane       int
+	Tile       int
+	X          int
+	Y          int
+	Column     int
+	Row        int
+	Number     int
+	UMI        string
+	Read       int
+	Filtered   bool
+	Control    int
+	Index      string
+}
+
+// Patterns for read names and the Casava 1.8 comment
+var (
+	casavaNameRex    = regexp.MustCompile(`^([^:\s]+):(\d+):([^:\s]+):(\d+):(\d+):(\d+):(\d+)(?::([^:\s]+))?$`)
+	casavaCommentRex = regexp.MustCompile(`^([1-4]):([YN]):(\d+):(\S*)`)
+	illuminaNameRex  = regexp.MustCompile(`^([^:\s]+):(\d+):(\d+):(-?\d+):(-?\d+)(?:#([^/\s]+))?(?:/([1-4]))?$`)
+	mgiNameRex       = regexp.MustCompile(`^([A-Z0-9]+)L(\d{1,2})C(\d{3})R(\d{3})(\d+)(?:/([1-4]))?$`)
+)
+
+// ParseReadHeader parses a read Id (without the leading @) in Casava
+// 1.8+, pre-Casava 1.8 Illumina or MGI/BGI format. The Casava 1.8
+// comment (1:N:0:ACGT) is also recognised after an MGI name. In a
+// Casava header Index holds the index sequence(s), for example
+// ACGTACGT+TTGATCCA for dual indexes, or the sample number, exactly as
+// written.
+func ParseReadHeader(id string) (*ReadHeader, error) {
+	name, comment, _ := strings.Cut(id, ` `)
+	comment = strings.TrimSpace(comment)
+	h := &ReadHeader{}
+
+	if m := casavaNameRex.FindStringSubmatch(name); m != nil {
+		h.Format = CasavaHeader
+		h.Instrument = m[1]
+		h.Run = atoi(m[2])
+		h.Flowcell = m[3]
+		h.Lane = atoi(m[4])
+		h.Tile = atoi(m[5])
+		h.X = atoi(m[6])
+		h.Y = atoi(m[7])
+		h.UMI = m[8]
+	} else if m := illuminaNameRex.FindStringSubmatch(name); m != nil {
+		h.Format = IlluminaHeader
+		h.Instrument = m[1]
+		h.Lane = atoi(m[2])
+		h.Tile = atoi(m[3])
+		h.X = atoi(m[4])
+		h.Y = atoi(m[5])
+		h.Index = m[6]
+		h.Read = atoi(m[7])
+	} else if m := mgiNameRex.FindStringSubmatch(name); m != nil {
+		h.Format = MgiHeader
+		h.Flowcell = m[1]
+		h.Lane = atoi(m[2])
+		h.Column = atoi(m[3])
+		h.Row = atoi(m[4])
+		h.Number = atoi(m[5])
+		h.Read = atoi(m[6])
+	} else {
+		return nil, fmt.Errorf("genome.ParseReadHeader: %s is not an Illumina or MGI read name", id)
+	}
+
+	if m := casavaCommentRex.FindStringSubmatch(comment); m != nil && h.Format != IlluminaHeader {
+		h.Read = atoi(m[1])
+		h.Filtered = m[2] == `Y`
+		h.Control = atoi(m[3])
+		h.Index = m[4]
+	} else if h.Format == CasavaHeader && comment != `` {
+		return nil, fmt.Errorf("genome.ParseReadHeader: %s has an invalid Casava comment", id)
+	}
+	return h, nil
+}
+
+// atoi is strconv.Atoi for strings already checked by a regexp where
+// an empty string means 0.
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+// Header parses the Id of the record with ParseReadHeader.
+func (r *FastqRec) Header() (*ReadHeader, error) {
+	return ParseReadHeader(r.Id)
+}
+
+// TileId identifies the tile (Illumina) or field of view (MGI) that
+// the read came from, for example H7KJ2DSXX:1:1101 or
+// V300012345:L1:C001R001, so reads can be grouped for per-tile QC.
+// Pre-Casava 1.8 headers have no flowcell so the instrument is used.
+func (h *ReadHeader) TileId() string {
+	switch h.Format {
+	case CasavaHeader:
+		return fmt.Sprintf("%s:%d:%d", h.Flowcell, h.Lane, h.Tile)
+	case IlluminaHeader:
+		return fmt.Sprintf("%s:%d:%d", h.Instrument, h.Lane, h.Tile)
+	case MgiHeader:
+		return fmt.Sprintf("%s:L%d:C%03dR%03d", h.Flowcell, h.Lane, h.Column, h.Row)
+	}
+	return ``
+}
+
+// IndexSequences splits Index into its i7 and (for dual indexing) i5
+// sequences. Both are empty if Index is a sample number rather than a
+// sequence.
+func (h *ReadHeader) IndexSequences() (string, string) {
+	i7, i5, _ := strings.Cut(h.Index, `+`)
+	if _, err := strconv.Atoi(i7); err == nil {
+		return ``, ``
+	}
+	return i7, i5
+}
@@ -0,0 +1,81 @@
+package genome
+
+import (
+	"testing"
+)
+
+func TestParseReadHeader(t *testing.T) {
+	tests := []struct {
+		id   string
+		want ReadHeader
+		tile string
+	}{
+		{`A00123:8:H7KJ2DSXX:1:1101:10004:10019 1:N:0:ACGTACGT+TTGATCCA`,
+			ReadHeader{Format: CasavaHeader, Instrument: `A00123`, Run: 8,
+				Flowcell: `H7KJ2DSXX`, Lane: 1, Tile: 1101, X: 10004, Y: 10019,
+				Read: 1, Index: `ACGTACGT+TTGATCCA`},
+			`H7KJ2DSXX:1:1101`},
+		{`M00123:45:000000000-ABCDE:1:2114:15589:1331:GATCTTAC 2:Y:18:3`,
+			ReadHeader{Format: CasavaHeader, Instrument: `M00123`, Run: 45,
+				Flowcell: `000000000-ABCDE`, Lane: 1, Tile: 2114, X: 15589, Y: 1331,
+				UMI: `GATCTTAC`, Read: 2, Filtered: true, Control: 18, Index: `3`},
+			`000000000-ABCDE:1:2114`},
+		{`A00123:8:H7KJ2DSXX:2:1101:10004:10019`,
+			ReadHeader{Format: CasavaHeader, Instrument: `A00123`, Run: 8,
+				Flowcell: `H7KJ2DSXX`, Lane: 2, Tile: 1101, X: 10004, Y: 10019},
+			`H7KJ2DSXX:2:1101`},
+		{`HWUSI-EAS100R:6:73:941:1973#ACGT/1`,
+			ReadHeader{Format: IlluminaHeader, Instrument: `HWUSI-EAS100R`,
+				Lane: 6, Tile: 73, X: 941, Y: 1973, Read: 1, Index: `ACGT`},
+			`HWUSI-EAS100R:6:73`},
+		{`HWUSI-EAS100R:6:73:941:1973#0`,
+			ReadHeader{Format: IlluminaHeader, Instrument: `HWUSI-EAS100R`,
+				Lane: 6, Tile: 73, X: 941, Y: 1973, Index: `0`},
+			`HWUSI-EAS100R:6:73`},
+		{`V300012345L1C001R0010000001/2`,
+			ReadHeader{Format: MgiHeader, Flowcell: `V300012345`, Lane: 1,
+				Column: 1, Row: 1, Number: 1, Read: 2},
+			`V300012345:L1:C001R001`},
+		{`E100004567L2C012R0340012345 1:N:0:AACCGGTT`,
+			ReadHeader{Format: MgiHeader, Flowcell: `E100004567`, Lane: 2,
+				Column: 12, Row: 34, Number: 12345, Read: 1, Index: `AACCGGTT`},
+			`E100004567:L2:C012R034`},
+	}
+	for _, tt := range tests {
+		h, err := ParseReadHeader(tt.id)
+		if err != nil {
+			t.Fatalf(`ParseReadHeader(%s) failed: %v`, tt.id, err)
+		}
+		if *h != tt.want {
+			t.Fatalf(`ParseReadHeader(%s) should be %+v but is %+v`, tt.id, tt.want, *h)
+		}
+		if h.TileId() != tt.tile {
+			t.Fatalf(`TileId for %s should be %s but is %s`, tt.id, tt.tile, h.TileId())
+		}
+	}
+
+	for _, id := range []string{`read1`, `SRR001666.1 071112_SLXA-EAS1_s_7:5:1:817:345 length=36`,
+		`A00123:8:H7KJ2DSXX:1:1101:10004:10019 1:X:0:ACGT`} {
+		if _, err := ParseReadHeader(id); err == nil {
+			t.Fatalf(`ParseReadHeader(%s) should fail`, id)
+		}
+	}
+}
+
+func TestIndexSequences(t *testing.T) {
+	tests := []struct {
+		index, i7, i5 string
+	}{
+		{`ACGTACGT+TTGATCCA`, `ACGTACGT`, `TTGATCCA`},
+		{`ACGTACGT`, `ACGTACGT`, ``},
+		{`3`, ``, ``},
+		{``, ``, ``},
+	}
+	for _, tt := range tests {
+		h := &ReadHeader{Index: tt.index}
+		i7, i5 := h.IndexSequences()
+		if i7 != tt.i7 || i5 != tt.i5 {
+			t.Fatalf(`IndexSequences(%s) should be %s %s but is %s %s`, tt.index, tt.i7, tt.i5, i7, i5)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package genome
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grendeloz/ngs/selector"
+)
+
+// Selectors can be applied to a Genome or to the records read from a
+// FastaFile. The Subject of a selector can be:
+//
+//	name   - the FastaRec Name
+//	header - the complete FastaRec Header line
+//	info   - the FastaRec Info
+//
+// and the Operation can be keep or delete. For example, to keep only
+// the primary human chromosomes:
+//
+//	keep:name:^chr[0-9XYM]+$
+//
+// or to drop unplaced contigs and alternate haplotypes:
+//
+//	delete:name:^(GL|KI)
+//	delete:name:_alt$
+
+// recSelector is a Selector with its Pattern compiled.
+type recSelector struct {
+	sel *selector.Selector
+	re  *regexp.Regexp
+}
+
+func newRecSelector(sel *selector.Selector) (*recSelector, error) {
+	switch sel.Operation {
+	case `keep`, `delete`:
+	default:
+		return nil, fmt.Errorf("selector operation not recognised in: %s", sel)
+	}
+	switch sel.Subject {
+	case `name`, `header`, `info`:
+	default:
+		return nil, fmt.Errorf("selector subject not recognised in: %s", sel)
+	}
+	re, err := regexp.Compile(sel.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("selector pattern not valid in: %s: %w", sel, err)
+	}
+	return &recSelector{sel: sel, re: re}, nil
+}
+
+// retains returns true if the FastaRec survives the selector.
+func (rs *recSelector) retains(r *FastaRec) bool {
+	var subject string
+	switch rs.sel.Subject {
+	case `name`:
+		subject = r.Name
+	case `header`:
+		subject = r.Header
+	case `info`:
+		subject = r.Info
+	}
+	match := rs.re.MatchString(subject)
+	if rs.sel.Operation == `keep` {
+		return match
+	}
+	return !match
+}
+
+func newRecSelectors(sels []*selector.Selector) ([]*recSelector, error) {
+	var rss []*recSelector
+	for _, sel := range sels {
+		rs, err := newRecSelector(sel)
+		if err != nil {
+			return nil, err
+		}
+		rss = append(rss, rs)
+	}
+	return rss, nil
+}
+
+// Rename changes the Name of the FastaRec and rewrites the Header to
+// match. Info is unchanged.
+func (r *FastaRec) Rename(name string) {
+	r.Name = name
+	r.Header = `>` + name
+	if r.Info != `` {
+		r.Header += ` ` + r.Info
+	}
+}
+
+// ApplySelectors drops sequences from the Genome. The selectors are
+// applied in order and a sequence is dropped as soon as any selector
+// drops it. A new Provenance record is added whose Args are
+// genome.Genome.ApplySelectors followed by the selectors so the
+// selection is recorded with the Genome.
+func (g *Genome) ApplySelectors(sels ...*selector.Selector) error {
+	rss, err := newRecSelectors(sels)
+	if err != nil {
+		return fmt.Errorf("genome.Genome.ApplySelectors: %w", err)
+	}
+
+	if g.lazy != nil {
+		// Selectors only see the name, header and info, which are
+		// known without loading the sequences.
+		var kept []string
+		for _, n := range g.lazy.names {
+			r := g.lazy.get(n)
+			if r == nil {
+				r = g.lazy.record(n)
+			}
+			if retains(rss, r) {
+				kept = append(kept, n)
+			}
+		}
+		g.lazy.retain(kept)
+	} else {
+		var kept []*FastaRec
+		for _, r := range g.Sequences {
+			if retains(rss, r) {
+				kept = append(kept, r)
+			}
+		}
+		g.Sequences = kept
+		g.resetSeqIndex()
+	}
+
+	args := []string{`genome.Genome.ApplySelectors`}
+	for _, sel := range sels {
+		args = append(args, sel.String())
+	}
+	g.addProvenanceArgs(args)
+	return nil
+}
+
+func retains(rss []*recSelector, r *FastaRec) bool {
+	for _, rs := range rss {
+		if !rs.retains(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rename renames the sequences in the Genome using names which maps old
+// names to new names. Sequences not in names are unchanged. It is an
+// error if renaming would give two sequences the same name, in which
+// case the Genome is not changed. The number of sequences renamed is
+// returned and, if it is not zero, a new Provenance record is added
+// whose Args are genome.Genome.Rename followed by old=new for each
+// sequence renamed.
+func (g *Genome) Rename(names map[string]string) (int, error) {
+	seen := make(map[string]bool)
+	for _, name := range g.SequenceNames() {
+		if n, ok := names[name]; ok {
+			name = n
+		}
+		if seen[name] {
+			return 0, fmt.Errorf("genome.Genome.Rename: more than one sequence would be named %s", name)
+		}
+		seen[name] = true
+	}
+
+	var renamed []string
+	for _, name := range g.SequenceNames() {
+		if n, ok := names[name]; ok && n != name {
+			renamed = append(renamed, name+`=`+n)
+		}
+	}
+	if g.lazy != nil {
+		g.lazy.rename(names)
+	} else {
+		for _, r := range g.Sequences {
+			if n, ok := names[r.Name]; ok && n != r.Name {
+				r.Rename(n)
+			}
+		}
+		g.resetSeqIndex()
+	}
+	if len(renamed) > 0 {
+		sort.Strings(renamed)
+		g.addProvenanceArgs(append([]string{`genome.Genome.Rename`}, renamed...))
+	}
+	return len(renamed), nil
+}
+
+// addProvenanceArgs adds a new Provenance record with Args set to args
+// rather than the command line.
+func (g *Genome) addProvenanceArgs(args []string) {
+	g.AddProvenance()
+	g.Provenance[0].Args = args
+}
+
+// ReadRenameMap reads a file of old and new sequence names, one pair
+// per line separated by whitespace, as used by Genome.Rename and
+// FastaFile.SetRenames. Blank lines and lines starting with # are
+// ignored. It is an error for an old name to appear more than once.
+func ReadRenameMap(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	names := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lctr := 0
+	for scanner.Scan() {
+		lctr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == `` || strings.HasPrefix(line, `#`) {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("genome.ReadRenameMap: line %d should have 2 fields but has %d", lctr, len(fields))
+		}
+		if _, ok := names[fields[0]]; ok {
+			return nil, fmt.Errorf("genome.ReadRenameMap: line %d: %s already renamed", lctr, fields[0])
+		}
+		names[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
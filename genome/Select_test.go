@@ -0,0 +1,123 @@
+package genome
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grendeloz/ngs/selector"
+)
+
+func TestGenomeApplySelectors(t *testing.T) {
+	g := NewGenome(`test`)
+	for _, h := range []string{`>chr1`, `>chr1_KI270706v1_random`, `>GL000191.1`, `>chrX`, `>chr6_GL000250v2_alt`} {
+		g.Sequences = append(g.Sequences, NewFastaRec(h))
+	}
+
+	sels, err := selector.NewFromStrings([]string{`delete:name:_alt$`, `keep:name:^chr[0-9XYM]+`})
+	if err != nil {
+		t.Fatalf(`NewFromStrings failed: %v`, err)
+	}
+	provs := len(g.Provenance)
+	if err := g.ApplySelectors(sels...); err != nil {
+		t.Fatalf(`ApplySelectors failed: %v`, err)
+	}
+	e := []string{`chr1`, `chr1_KI270706v1_random`, `chrX`}
+	if len(g.Sequences) != len(e) {
+		t.Fatalf(`ApplySelectors should keep %d sequences but kept %d`, len(e), len(g.Sequences))
+	}
+	for i, r := range g.Sequences {
+		if r.Name != e[i] {
+			t.Fatalf(`sequence %d should be %s but is %s`, i, e[i], r.Name)
+		}
+	}
+	if len(g.Provenance) != provs+1 || len(g.Provenance[0].Args) != 3 ||
+		g.Provenance[0].Args[1] != `delete:name:_alt$` {
+		t.Fatalf(`ApplySelectors Provenance incorrect: %v`, g.Provenance[0].Args)
+	}
+
+	bad := []string{`drop:name:^chr`, `keep:length:^1`, `keep:name:(`}
+	for _, b := range bad {
+		sel, _ := selector.NewFromString(b)
+		if err := g.ApplySelectors(sel); err == nil {
+			t.Fatalf(`ApplySelectors(%s) should fail`, b)
+		}
+	}
+}
+
+func TestGenomeRename(t *testing.T) {
+	g := NewGenome(`test`)
+	g.Sequences = append(g.Sequences, NewFastaRec(`>1 some info`), NewFastaRec(`>2`))
+
+	if _, err := g.Rename(map[string]string{`1`: `2`}); err == nil {
+		t.Fatalf(`Rename to an existing name should fail`)
+	}
+	if g.Sequences[0].Name != `1` {
+		t.Fatalf(`failed Rename should not change the Genome`)
+	}
+
+	n, err := g.Rename(map[string]string{`1`: `chr1`, `2`: `chr2`, `3`: `chr3`})
+	if err != nil {
+		t.Fatalf(`Rename failed: %v`, err)
+	}
+	if n != 2 {
+		t.Fatalf(`Rename should rename 2 sequences but renamed %d`, n)
+	}
+	if g.Sequences[0].Name != `chr1` || g.Sequences[0].Header != `>chr1 some info` {
+		t.Fatalf(`renamed sequence incorrect: %s %s`, g.Sequences[0].Name, g.Sequences[0].Header)
+	}
+	if g.Provenance[0].Args[1] != `1=chr1` {
+		t.Fatalf(`Rename Provenance incorrect: %v`, g.Provenance[0].Args)
+	}
+}
+
+func TestFastaFileSelectors(t *testing.T) {
+	file := "testdata/GRCh37_test.fa.gz"
+	ff, err := OpenFastaFile(file)
+	if err != nil {
+		t.Fatalf(`OpenFastaFile(%s) failed: %v`, file, err)
+	}
+	sel, _ := selector.NewFromString(`delete:name:^GL`)
+	if err := ff.AddSelectors(sel); err != nil {
+		t.Fatalf(`AddSelectors failed: %v`, err)
+	}
+	ff.SetRenames(map[string]string{`chrMT`: `chrM`})
+
+	g := NewGenome(`test`)
+	if err := g.AddFasta(ff); err != nil {
+		t.Fatalf(`AddFasta failed: %v`, err)
+	}
+	if len(g.Sequences) != 25 || ff.RecordCount() != 25 {
+		t.Fatalf(`AddFasta should add 25 sequences but added %d`, len(g.Sequences))
+	}
+	if g.Sequences[24].Name != `chrM` {
+		t.Fatalf(`last sequence should be chrM but is %s`, g.Sequences[24].Name)
+	}
+	e := []string{`genome.Genome.AddFasta`, file, `delete:name:^GL`, `chrMT=chrM`}
+	for i := range e {
+		if g.Provenance[0].Args[i] != e[i] {
+			t.Fatalf(`AddFasta Provenance should be %v but is %v`, e, g.Provenance[0].Args)
+		}
+	}
+}
+
+func TestReadRenameMap(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "names.txt")
+	if err := os.WriteFile(file, []byte("# UCSC to Ensembl\nchr1\t1\n\nchrM  MT\n"), 0644); err != nil {
+		t.Fatalf(`unable to write %s: %v`, file, err)
+	}
+	names, err := ReadRenameMap(file)
+	if err != nil {
+		t.Fatalf(`ReadRenameMap failed: %v`, err)
+	}
+	if len(names) != 2 || names[`chrM`] != `MT` {
+		t.Fatalf(`ReadRenameMap incorrect: %v`, names)
+	}
+
+	if err := os.WriteFile(file, []byte("chr1\t1\nchr1\t01\n"), 0644); err != nil {
+		t.Fatalf(`unable to write %s: %v`, file, err)
+	}
+	if _, err := ReadRenameMap(file); err == nil {
+		t.Fatalf(`ReadRenameMap should fail on a repeated name`)
+	}
+}
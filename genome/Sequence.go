@@ -98,3 +98,84 @@ func (s *Sequence) SubSequence(start, end int) (string, error) {
 	//           to 0-based half-open go substring coords ...
 	return s.Sequence[start-1 : end], nil
 }
+
+// ReverseComplement returns a new Sequence with the same Name holding
+// the reverse complement of this Sequence. IUPAC ambiguity codes are
+// complemented (R<->Y, K<->M, B<->V, D<->H, S, W and N are unchanged)
+// and case is preserved so soft-masking survives. If the Sequence
+// contains any U it is taken to be RNA and the complement of A is U
+// rather than T.
+func (s *Sequence) ReverseComplement() *Sequence {
+	rc := reverseComplement([]byte(s.Sequence))
+	return &Sequence{Name: s.Name, Sequence: string(rc)}
+}
+
+// Alphabet returns the most restrictive Alphabet that every character
+// in the Sequence belongs to, trying DNA, RNA, IUPAC and Protein in
+// that order. An empty Sequence is DNA. If no Alphabet fits,
+// UnknownAlphabet is returned and Validate will show the problem.
+func (s *Sequence) Alphabet() Alphabet {
+	for _, a := range []Alphabet{DNA, RNA, IUPAC, Protein} {
+		if a.invalidPosition(s.Sequence) < 0 {
+			return a
+		}
+	}
+	return UnknownAlphabet
+}
+
+// Validate checks that every character of the Sequence is in Alphabet
+// a. The error for an invalid Sequence gives the offending character
+// and its 1-based position so the problem can be found in the source
+// file.
+func (s *Sequence) Validate(a Alphabet) error {
+	if _, ok := alphabetTables[a]; !ok {
+		return fmt.Errorf("genome.Sequence.Validate: cannot validate against alphabet %s", a)
+	}
+	if i := a.invalidPosition(s.Sequence); i >= 0 {
+		return fmt.Errorf("genome.Sequence.Validate: %s has invalid %s character %q at position %d",
+			s.Name, a, s.Sequence[i], i+1)
+	}
+	return nil
+}
+
+// ToRNA returns a new Sequence with the same Name in which every T has
+// been replaced by U, preserving case. It is an error to call ToRNA on
+// a Sequence that is not a nucleotide sequence.
+func (s *Sequence) ToRNA() (*Sequence, error) {
+	if err := s.Validate(IUPAC); err != nil {
+		return nil, fmt.Errorf("genome.Sequence.ToRNA: %w", err)
+	}
+	b := []byte(s.Sequence)
+	dnaToRna(b)
+	return &Sequence{Name: s.Name, Sequence: string(b)}, nil
+}
+
+// ToDNA returns a new Sequence with the same Name in which every U has
+// been replaced by T, preserving case. It is an error to call ToDNA on
+// a Sequence that is not a nucleotide sequence.
+func (s *Sequence) ToDNA() (*Sequence, error) {
+	if err := s.Validate(IUPAC); err != nil {
+		return nil, fmt.Errorf("genome.Sequence.ToDNA: %w", err)
+	}
+	b := []byte(s.Sequence)
+	for i, c := range b {
+		switch c {
+		case 'U':
+			b[i] = 'T'
+		case 'u':
+			b[i] = 't'
+		}
+	}
+	return &Sequence{Name: s.Name, Sequence: string(b)}, nil
+}
+
+func dnaToRna(b []byte) {
+	for i, c := range b {
+		switch c {
+		case 'T':
+			b[i] = 'U'
+		case 't':
+			b[i] = 'u'
+		}
+	}
+}
@@ -111,3 +111,83 @@ func TestWithinLimits(t *testing.T) {
 			e6, false, g6, ok)
 	}
 }
+
+func TestReverseComplement(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want string
+	}{
+		{`ACGTTGCA`, `TGCAACGT`},
+		{`acgtNNAC`, `GTNNacgt`},
+		{`RYKMBVDHSWN`, `NWSDHBVKMRY`},
+		{`ACGUUGCA`, `UGCAACGU`},
+		{`AAAAU`, `AUUUU`},
+		{`acguRN`, `NYacgu`},
+		{``, ``},
+	}
+	for _, tc := range tests {
+		s := &Sequence{Name: "test", Sequence: tc.seq}
+		got := s.ReverseComplement()
+		if got.Sequence != tc.want {
+			t.Fatalf(`ReverseComplement of %s should be %s but is %s`,
+				tc.seq, tc.want, got.Sequence)
+		}
+		if got.Name != s.Name {
+			t.Fatalf(`ReverseComplement Name should be %s but is %s`, s.Name, got.Name)
+		}
+	}
+}
+
+func TestAlphabet(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want Alphabet
+	}{
+		{`ACGTNacgtn`, DNA},
+		{`ACGUNacgun`, RNA},
+		{`ACGTRYKM`, IUPAC},
+		{`MSTNKQ*`, Protein},
+		{`ACGT-ACGT`, UnknownAlphabet},
+	}
+	for _, tc := range tests {
+		s := &Sequence{Name: "test", Sequence: tc.seq}
+		if got := s.Alphabet(); got != tc.want {
+			t.Fatalf(`Alphabet of %s should be %s but is %s`, tc.seq, tc.want, got)
+		}
+	}
+
+	s := &Sequence{Name: "test", Sequence: `ACGTACRTAC`}
+	if err := s.Validate(IUPAC); err != nil {
+		t.Fatalf(`Validate(IUPAC) should have passed but failed: %v`, err)
+	}
+	err := s.Validate(DNA)
+	if err == nil {
+		t.Fatalf(`Validate(DNA) should have failed`)
+	}
+	e1 := `genome.Sequence.Validate: test has invalid DNA character 'R' at position 7`
+	if err.Error() != e1 {
+		t.Fatalf(`Validate error should be [%s] but is [%s]`, e1, err)
+	}
+}
+
+func TestToRNA(t *testing.T) {
+	s := &Sequence{Name: "test", Sequence: `ACGTacgt`}
+	r, err := s.ToRNA()
+	if err != nil {
+		t.Fatalf(`ToRNA failed: %v`, err)
+	}
+	if r.Sequence != `ACGUacgu` {
+		t.Fatalf(`ToRNA should be ACGUacgu but is %s`, r.Sequence)
+	}
+	d, err := r.ToDNA()
+	if err != nil {
+		t.Fatalf(`ToDNA failed: %v`, err)
+	}
+	if d.Sequence != s.Sequence {
+		t.Fatalf(`ToDNA should be %s but is %s`, s.Sequence, d.Sequence)
+	}
+	p := &Sequence{Name: "test", Sequence: `MEEPQ`}
+	if _, err := p.ToRNA(); err == nil {
+		t.Fatalf(`ToRNA on protein should have failed`)
+	}
+}
@@ -0,0 +1,195 @@
+package genome
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SequenceStats holds base composition counts for a single sequence.
+// GC counts G, C and S (strong) bases and AT counts A, T, U and W
+// (weak) bases, in either case. N counts N bases and Gaps is the number
+// of runs of one or more consecutive Ns. SoftMasked counts lowercase
+// bases.
+type SequenceStats struct {
+	Name       string `json:"name"`
+	Length     int    `json:"length"`
+	GC         int    `json:"gc"`
+	AT         int    `json:"at"`
+	N          int    `json:"n"`
+	Gaps       int    `json:"gaps"`
+	SoftMasked int    `json:"soft_masked"`
+}
+
+// AssemblyStats is a summary of all of the sequences in a Genome or
+// FASTA file. Nx is the length of the shortest sequence in the
+// smallest set of longest sequences that together hold x% of the total
+// length and Lx is the number of sequences in that set. GCPercent is
+// calculated over bases that are known to be strong or weak so Ns and
+// other ambiguity codes do not dilute it.
+type AssemblyStats struct {
+	Name          string           `json:"name"`
+	UUID          string           `json:"uuid,omitempty"`
+	SequenceCount int              `json:"sequence_count"`
+	TotalLength   int              `json:"total_length"`
+	MinLength     int              `json:"min_length"`
+	MaxLength     int              `json:"max_length"`
+	N50           int              `json:"n50"`
+	L50           int              `json:"l50"`
+	N90           int              `json:"n90"`
+	L90           int              `json:"l90"`
+	GC            int              `json:"gc"`
+	AT            int              `json:"at"`
+	GCPercent     float64          `json:"gc_percent"`
+	N             int              `json:"n"`
+	Gaps          int              `json:"gaps"`
+	SoftMasked    int              `json:"soft_masked"`
+	Sequences     []*SequenceStats `json:"sequences"`
+}
+
+// Stats calculates the base composition of the FastaRec.
+func (r *FastaRec) Stats() *SequenceStats {
+	ss := &SequenceStats{Name: r.Name, Length: len(r.Sequence)}
+	inGap := false
+	for i := 0; i < len(r.Sequence); i++ {
+		c := r.Sequence[i]
+		if c >= 'a' && c <= 'z' {
+			ss.SoftMasked++
+		}
+		switch toUpper(c) {
+		case 'G', 'C', 'S':
+			ss.GC++
+		case 'A', 'T', 'U', 'W':
+			ss.AT++
+		case 'N':
+			ss.N++
+			if !inGap {
+				ss.Gaps++
+			}
+			inGap = true
+			continue
+		}
+		inGap = false
+	}
+	return ss
+}
+
+// Stats calculates AssemblyStats for all of the sequences in the
+// Genome.
+func (g *Genome) Stats() (*AssemblyStats, error) {
+	var seqs []*SequenceStats
+	err := g.eachSequence(func(r *FastaRec) error {
+		seqs = append(seqs, r.Stats())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("genome.Genome.Stats: %w", err)
+	}
+	as := NewAssemblyStats(g.Name, seqs)
+	as.UUID = g.UUID
+	return as, nil
+}
+
+// Stats calculates AssemblyStats for the records remaining in the
+// FASTA file. Only one record is held in memory at a time so this is
+// suitable for assemblies that are too large to load as a Genome.
+// Like ReadAll, it starts from the current position in the file.
+func (f *FastaFile) Stats() (*AssemblyStats, error) {
+	var seqs []*SequenceStats
+	for {
+		r, err := f.Next()
+		if err != nil {
+			return nil, fmt.Errorf("genome.FastaFile.Stats: %w", err)
+		}
+		if r == nil {
+			break
+		}
+		seqs = append(seqs, r.Stats())
+	}
+	return NewAssemblyStats(f.Filepath, seqs), nil
+}
+
+// NewAssemblyStats summarises a list of SequenceStats. The order of
+// seqs is kept in AssemblyStats.Sequences.
+func NewAssemblyStats(name string, seqs []*SequenceStats) *AssemblyStats {
+	as := &AssemblyStats{Name: name, Sequences: seqs}
+	as.SequenceCount = len(seqs)
+
+	var lengths []int
+	for _, s := range seqs {
+		as.TotalLength += s.Length
+		as.GC += s.GC
+		as.AT += s.AT
+		as.N += s.N
+		as.Gaps += s.Gaps
+		as.SoftMasked += s.SoftMasked
+		lengths = append(lengths, s.Length)
+	}
+	if as.GC+as.AT > 0 {
+		as.GCPercent = 100 * float64(as.GC) / float64(as.GC+as.AT)
+	}
+	if len(lengths) == 0 {
+		return as
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	as.MaxLength = lengths[0]
+	as.MinLength = lengths[len(lengths)-1]
+	as.N50, as.L50 = nx(lengths, as.TotalLength, 50)
+	as.N90, as.L90 = nx(lengths, as.TotalLength, 90)
+
+	return as
+}
+
+// nx returns the Nx and Lx values for lengths which must be sorted
+// longest first.
+func nx(lengths []int, total int, x int) (int, int) {
+	var sum int
+	for i, l := range lengths {
+		sum += l
+		// Integer form of sum >= total*x/100 that avoids rounding.
+		if sum*100 >= total*x {
+			return l, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// JSON returns the AssemblyStats as indented JSON.
+func (as *AssemblyStats) JSON() ([]byte, error) {
+	j, err := json.MarshalIndent(as, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("genome.AssemblyStats.JSON: %w", err)
+	}
+	return j, nil
+}
+
+// String returns the AssemblyStats as human-readable text: a block of
+// summary values followed by a tab-separated table with one line per
+// sequence.
+func (as *AssemblyStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name\t%s\n", as.Name)
+	if as.UUID != "" {
+		fmt.Fprintf(&b, "UUID\t%s\n", as.UUID)
+	}
+	fmt.Fprintf(&b, "Sequences\t%d\n", as.SequenceCount)
+	fmt.Fprintf(&b, "Total length\t%d\n", as.TotalLength)
+	fmt.Fprintf(&b, "Min length\t%d\n", as.MinLength)
+	fmt.Fprintf(&b, "Max length\t%d\n", as.MaxLength)
+	fmt.Fprintf(&b, "N50\t%d\n", as.N50)
+	fmt.Fprintf(&b, "L50\t%d\n", as.L50)
+	fmt.Fprintf(&b, "N90\t%d\n", as.N90)
+	fmt.Fprintf(&b, "L90\t%d\n", as.L90)
+	fmt.Fprintf(&b, "GC%%\t%.2f\n", as.GCPercent)
+	fmt.Fprintf(&b, "N count\t%d\n", as.N)
+	fmt.Fprintf(&b, "Gaps\t%d\n", as.Gaps)
+	fmt.Fprintf(&b, "Soft-masked\t%d\n", as.SoftMasked)
+	b.WriteString("\n#name\tlength\tgc\tat\tn\tgaps\tsoft_masked\n")
+	for _, s := range as.Sequences {
+		fmt.Fprintf(&b, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			s.Name, s.Length, s.GC, s.AT, s.N, s.Gaps, s.SoftMasked)
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package genome
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGenomeStats(t *testing.T) {
+	g := NewGenome("testing")
+	lengths := []int{50, 30, 10, 10}
+	seqs := []string{
+		`ACGTACGTACGTACGTACGTNNNNNacgtacgtacgtacgtacgtNNNNN`,
+		`GGGGGGGGGGCCCCCCCCCCAAAAAAAAAA`,
+		`NNNNNNNNNN`,
+		`ATATATATAT`,
+	}
+	for i, s := range seqs {
+		r := NewFastaRec(">seq" + string(rune('1'+i)))
+		r.Sequence = s
+		if r.Length() != lengths[i] {
+			t.Fatalf(`test sequence %d length should be %d but is %d`, i, lengths[i], r.Length())
+		}
+		g.Sequences = append(g.Sequences, r)
+	}
+
+	as, err := g.Stats()
+	if err != nil {
+		t.Fatalf(`Stats failed: %v`, err)
+	}
+	tests := []struct {
+		name string
+		want int
+		got  int
+	}{
+		{"SequenceCount", 4, as.SequenceCount},
+		{"TotalLength", 100, as.TotalLength},
+		{"MinLength", 10, as.MinLength},
+		{"MaxLength", 50, as.MaxLength},
+		{"N50", 50, as.N50},
+		{"L50", 1, as.L50},
+		{"N90", 10, as.N90},
+		{"L90", 3, as.L90},
+		{"GC", 40, as.GC},
+		{"AT", 40, as.AT},
+		{"N", 20, as.N},
+		{"Gaps", 3, as.Gaps},
+		{"SoftMasked", 20, as.SoftMasked},
+		{"seq1.Gaps", 2, as.Sequences[0].Gaps},
+	}
+	for _, tc := range tests {
+		if tc.want != tc.got {
+			t.Fatalf(`%s should be %d but is %d`, tc.name, tc.want, tc.got)
+		}
+	}
+	if as.GCPercent != 50 {
+		t.Fatalf(`GCPercent should be 50 but is %f`, as.GCPercent)
+	}
+	if as.UUID != g.UUID {
+		t.Fatalf(`UUID should be %s but is %s`, g.UUID, as.UUID)
+	}
+
+	j, err := as.JSON()
+	if err != nil {
+		t.Fatalf(`JSON failed: %v`, err)
+	}
+	var as2 AssemblyStats
+	if err := json.Unmarshal(j, &as2); err != nil {
+		t.Fatalf(`JSON round trip failed: %v`, err)
+	}
+	if as2.N50 != as.N50 || len(as2.Sequences) != 4 {
+		t.Fatalf(`JSON round trip lost data: %s`, j)
+	}
+}
+
+func TestFastaFileStats(t *testing.T) {
+	file := "testdata/GRCh37_test.fa.gz"
+	faf, err := OpenFastaFile(file)
+	if err != nil {
+		t.Fatalf(`OpenFastaFile on %s failed: %v`, file, err)
+	}
+	as, err := faf.Stats()
+	if err != nil {
+		t.Fatalf(`FastaFile.Stats failed: %v`, err)
+	}
+
+	g := NewGenome("testing")
+	if err := g.AddFastaFile(file); err != nil {
+		t.Fatalf(`AddFastaFile on %s failed: %v`, file, err)
+	}
+	gs, err := g.Stats()
+	if err != nil {
+		t.Fatalf(`Stats failed: %v`, err)
+	}
+
+	if as.SequenceCount != 27 || as.SequenceCount != gs.SequenceCount {
+		t.Fatalf(`SequenceCount should be 27 but is %d/%d`, as.SequenceCount, gs.SequenceCount)
+	}
+	if as.TotalLength != gs.TotalLength || as.N50 != gs.N50 || as.GC != gs.GC {
+		t.Fatalf(`FastaFile and Genome stats differ: %v vs %v`, as, gs)
+	}
+	if as.MaxLength != 70000 {
+		t.Fatalf(`MaxLength should be 70000 but is %d`, as.MaxLength)
+	}
+}
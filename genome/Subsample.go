@@ -0,0 +1,264 @@
+package genome
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// FractionSampler chooses a fraction of reads reproducibly. Whether a
+// read is kept depends only on the seed and the read name (see
+// FastqPairName) so the same reads are chosen from R1 and R2, from
+// files in any order and on every run with the same seed.
+type FractionSampler struct {
+	Fraction float64
+	Seed     int64
+}
+
+// NewFractionSampler returns a FractionSampler that keeps the given
+// fraction (0-1) of reads.
+func NewFractionSampler(fraction float64, seed int64) (*FractionSampler, error) {
+	if fraction < 0 || fraction > 1 {
+		return nil, fmt.Errorf("genome.NewFractionSampler: fraction %f is not between 0 and 1", fraction)
+	}
+	return &FractionSampler{Fraction: fraction, Seed: seed}, nil
+}
+
+// Keep returns true if the read should be kept.
+func (s *FractionSampler) Keep(r *FastqRec) bool {
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], uint64(s.Seed))
+	h.Write(seed[:])
+	h.Write([]byte(FastqPairName(r.Id)))
+	// FNV alone is poorly distributed for similar short names so mix
+	// it with the splitmix64 finaliser then use the top 53 bits as a
+	// float in [0,1).
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11)/(1<<53) < s.Fraction
+}
+
+// ReservoirSample chooses exactly n records at random from the
+// remaining records of the file, or all of them if there are fewer
+// than n, in one pass and holding only n records in memory. The same
+// seed chooses the same records. The records are returned in file
+// order.
+func ReservoirSample(f *FastqFile, n int, seed int64) ([]*FastqRec, error) {
+	recs, err := reservoir(func() ([]*FastqRec, error) {
+		r, err := f.Next()
+		if r == nil || err != nil {
+			return nil, err
+		}
+		return []*FastqRec{r}, nil
+	}, n, seed)
+	if err != nil {
+		return nil, fmt.Errorf("genome.ReservoirSample: %w", err)
+	}
+	return recs[0], nil
+}
+
+// ReservoirSamplePairs is ReservoirSample for read pairs. It returns
+// the chosen R1 and R2 records in the same order.
+func ReservoirSamplePairs(p *FastqPairReader, n int, seed int64) ([]*FastqRec, []*FastqRec, error) {
+	recs, err := reservoir(func() ([]*FastqRec, error) {
+		r1, r2, err := p.Next()
+		if r1 == nil || err != nil {
+			return nil, err
+		}
+		return []*FastqRec{r1, r2}, nil
+	}, n, seed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("genome.ReservoirSamplePairs: %w", err)
+	}
+	return recs[0], recs[1], nil
+}
+
+// reservoir implements Algorithm R over groups of records returned by
+// next, which returns nil at the end. It returns one slice per record
+// in a group.
+func reservoir(next func() ([]*FastqRec, error), n int, seed int64) ([][]*FastqRec, error) {
+	type item struct {
+		pos  int
+		recs []*FastqRec
+	}
+	rng := rand.New(rand.NewSource(seed))
+	var res []item
+	width := 0
+	for i := 0; ; i++ {
+		recs, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if recs == nil {
+			break
+		}
+		width = len(recs)
+		if len(res) < n {
+			res = append(res, item{i, recs})
+		} else if j := rng.Intn(i + 1); j < n {
+			res[j] = item{i, recs}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].pos < res[j].pos })
+	// With no records, return enough empty slices for pairs
+	if width == 0 {
+		width = 2
+	}
+	out := make([][]*FastqRec, width)
+	for _, it := range res {
+		for k, r := range it.recs {
+			out[k] = append(out[k], r)
+		}
+	}
+	return out, nil
+}
+
+// ChunkOptions controls how FASTQ is split into chunks. A new chunk is
+// started when the current one has Records records or when the next
+// record would take it over Bytes bytes of uncompressed FASTQ. A limit
+// of 0 means no limit but at least one must be set. Chunks are written
+// with the Writer options, or the defaults if Writer is nil.
+type ChunkOptions struct {
+	Records int
+	Bytes   int64
+	Writer  *FastqWriterOptions
+}
+
+// NewChunkOptions returns options for chunks of 1,000,000 records with
+// no byte limit and the default writer.
+func NewChunkOptions() *ChunkOptions {
+	return &ChunkOptions{Records: 1000000, Writer: NewFastqWriterOptions()}
+}
+
+// ChunkFastq writes the remaining records of the file to a series of
+// chunk files and returns their names. The names are made by
+// formatting the chunk number, starting at 1, with pattern, for
+// example "sample.%04d.fastq.gz". If opts is nil, NewChunkOptions is
+// used.
+func ChunkFastq(f *FastqFile, pattern string, opts *ChunkOptions) ([]string, error) {
+	files, err := chunk(func() ([]*FastqRec, error) {
+		r, err := f.Next()
+		if r == nil || err != nil {
+			return nil, err
+		}
+		return []*FastqRec{r}, nil
+	}, []string{pattern}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("genome.ChunkFastq: %w", err)
+	}
+	return files[0], nil
+}
+
+// ChunkFastqPair is ChunkFastq for read pairs. R1 and R2 chunks have
+// the same records and a byte limit applies to each of them. It returns
+// the names of the R1 and R2 chunk files.
+func ChunkFastqPair(p *FastqPairReader, pattern1, pattern2 string, opts *ChunkOptions) ([]string, []string, error) {
+	files, err := chunk(func() ([]*FastqRec, error) {
+		r1, r2, err := p.Next()
+		if r1 == nil || err != nil {
+			return nil, err
+		}
+		return []*FastqRec{r1, r2}, nil
+	}, []string{pattern1, pattern2}, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("genome.ChunkFastqPair: %w", err)
+	}
+	return files[0], files[1], nil
+}
+
+// recordBytes returns the size of the record as written by FastqWriter.
+func recordBytes(r *FastqRec, repeatId bool) int64 {
+	n := len(r.Id) + len(r.Bases) + len(r.Qualities) + 6
+	if repeatId {
+		n += len(r.Id)
+	}
+	return int64(n)
+}
+
+// chunk writes groups of records returned by next to chunk files, one
+// file per pattern, and returns the file names for each pattern.
+func chunk(next func() ([]*FastqRec, error), patterns []string, opts *ChunkOptions) ([][]string, error) {
+	if opts == nil {
+		opts = NewChunkOptions()
+	}
+	if opts.Records < 1 && opts.Bytes < 1 {
+		return nil, fmt.Errorf("no record or byte limit for chunks")
+	}
+	for _, p := range patterns {
+		if !strings.Contains(p, `%`) {
+			return nil, fmt.Errorf("pattern %s has no %% verb for the chunk number", p)
+		}
+	}
+	repeatId := opts.Writer != nil && opts.Writer.RepeatId
+
+	files := make([][]string, len(patterns))
+	var writers []*FastqWriter
+	closeAll := func() error {
+		var first error
+		for _, w := range writers {
+			if err := w.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+		writers = nil
+		return first
+	}
+
+	records := 0
+	bytes := make([]int64, len(patterns))
+	for {
+		recs, err := next()
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		if recs == nil {
+			break
+		}
+
+		full := writers != nil && opts.Records > 0 && records >= opts.Records
+		for k, r := range recs {
+			if writers != nil && opts.Bytes > 0 && bytes[k] > 0 &&
+				bytes[k]+recordBytes(r, repeatId) > opts.Bytes {
+				full = true
+			}
+		}
+		if writers == nil || full {
+			if err := closeAll(); err != nil {
+				return nil, err
+			}
+			for k, p := range patterns {
+				file := fmt.Sprintf(p, len(files[k])+1)
+				w, err := CreateFastqWriter(file, opts.Writer)
+				if err != nil {
+					closeAll()
+					return nil, err
+				}
+				writers = append(writers, w)
+				files[k] = append(files[k], file)
+				bytes[k] = 0
+			}
+			records = 0
+		}
+
+		for k, r := range recs {
+			if err := writers[k].Write(r); err != nil {
+				closeAll()
+				return nil, err
+			}
+			bytes[k] += recordBytes(r, repeatId)
+		}
+		records++
+	}
+	if err := closeAll(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
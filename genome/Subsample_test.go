@@ -0,0 +1,145 @@
+package genome
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fastqPairContent(n int, read int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "@r%d/%d\nACGTACGT\n+\nIIIIIIII\n", i, read)
+	}
+	return sb.String()
+}
+
+func TestFractionSampler(t *testing.T) {
+	if _, err := NewFractionSampler(1.5, 1); err == nil {
+		t.Fatalf(`NewFractionSampler(1.5) should fail`)
+	}
+	s, _ := NewFractionSampler(0.25, 42)
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		r1 := &FastqRec{Id: fmt.Sprintf("r%d/1", i)}
+		r2 := &FastqRec{Id: fmt.Sprintf("r%d/2 2:N:0:ACGT", i)}
+		k := s.Keep(r1)
+		if k != s.Keep(r2) {
+			t.Fatalf(`Keep should be the same for R1 and R2 of r%d`, i)
+		}
+		if k {
+			kept++
+		}
+	}
+	if kept < 2300 || kept > 2700 {
+		t.Fatalf(`Keep(0.25) kept %d of 10000`, kept)
+	}
+
+	other, _ := NewFractionSampler(0.25, 43)
+	same := 0
+	for i := 0; i < 1000; i++ {
+		r := &FastqRec{Id: fmt.Sprintf("r%d", i)}
+		if s.Keep(r) == other.Keep(r) {
+			same++
+		}
+	}
+	if same == 1000 {
+		t.Fatalf(`different seeds should choose different reads`)
+	}
+}
+
+func TestReservoirSample(t *testing.T) {
+	file := writeFastq(t, fastqPairContent(100, 1))
+	sample := func(seed int64) []string {
+		ff, err := OpenFastqFile(file)
+		if err != nil {
+			t.Fatalf(`OpenFastqFile failed: %v`, err)
+		}
+		defer ff.Close()
+		recs, err := ReservoirSample(ff, 10, seed)
+		if err != nil {
+			t.Fatalf(`ReservoirSample failed: %v`, err)
+		}
+		var ids []string
+		for _, r := range recs {
+			ids = append(ids, r.Id)
+		}
+		return ids
+	}
+	a, b := sample(7), sample(7)
+	if len(a) != 10 || strings.Join(a, ` `) != strings.Join(b, ` `) {
+		t.Fatalf(`ReservoirSample with the same seed should give the same 10 reads: %v %v`, a, b)
+	}
+	if strings.Join(a, ` `) == strings.Join(sample(8), ` `) {
+		t.Fatalf(`ReservoirSample with different seeds should differ`)
+	}
+
+	p, _ := OpenFastqPair(file, writeFastq(t, fastqPairContent(100, 2)))
+	defer p.Close()
+	r1s, r2s, err := ReservoirSamplePairs(p, 10, 7)
+	if err != nil || len(r1s) != 10 || len(r2s) != 10 {
+		t.Fatalf(`ReservoirSamplePairs should give 10 pairs: %v`, err)
+	}
+	for i := range r1s {
+		if r1s[i].Id != a[i] || FastqPairName(r2s[i].Id) != FastqPairName(a[i]) {
+			t.Fatalf(`ReservoirSamplePairs pair %d is %s %s not %s`, i, r1s[i].Id, r2s[i].Id, a[i])
+		}
+	}
+
+	ff, _ := OpenFastqFile(writeFastq(t, fastqPairContent(3, 1)))
+	defer ff.Close()
+	if recs, _ := ReservoirSample(ff, 10, 1); len(recs) != 3 {
+		t.Fatalf(`ReservoirSample of 3 reads should return 3 but returned %d`, len(recs))
+	}
+}
+
+func TestChunkFastq(t *testing.T) {
+	dir := t.TempDir()
+	ff, _ := OpenFastqFile(writeFastq(t, fastqPairContent(25, 1)))
+	defer ff.Close()
+	opts := NewChunkOptions()
+	opts.Records = 10
+	files, err := ChunkFastq(ff, filepath.Join(dir, "chunk.%02d.fq"), opts)
+	if err != nil {
+		t.Fatalf(`ChunkFastq failed: %v`, err)
+	}
+	want := []int{10, 10, 5}
+	if len(files) != len(want) || filepath.Base(files[0]) != `chunk.01.fq` {
+		t.Fatalf(`ChunkFastq should write 3 chunks but wrote %v`, files)
+	}
+	for i, file := range files {
+		cf, _ := OpenFastqFile(file)
+		ids, _ := readAllFastq(t, cf)
+		cf.Close()
+		if len(ids) != want[i] {
+			t.Fatalf(`chunk %d should have %d records but has %d`, i+1, want[i], len(ids))
+		}
+	}
+
+	// Each record is 4+8+8+6 = 26 bytes so 60 bytes holds 2 records
+	p, _ := OpenFastqPair(writeFastq(t, fastqPairContent(5, 1)), writeFastq(t, fastqPairContent(5, 2)))
+	defer p.Close()
+	opts = &ChunkOptions{Bytes: 60}
+	f1, f2, err := ChunkFastqPair(p, filepath.Join(dir, "R1.%d.fq.gz"), filepath.Join(dir, "R2.%d.fq.gz"), opts)
+	if err != nil {
+		t.Fatalf(`ChunkFastqPair failed: %v`, err)
+	}
+	if len(f1) != 3 || len(f2) != 3 {
+		t.Fatalf(`ChunkFastqPair should write 3 chunks each but wrote %d and %d`, len(f1), len(f2))
+	}
+	b, err := os.ReadFile(f1[0])
+	if err != nil || len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		t.Fatalf(`chunks should be gzipped: %v`, err)
+	}
+	p, _ = OpenFastqPair(f1[2], f2[2])
+	defer p.Close()
+	if r1, r2, err := p.Next(); err != nil || r1.Id != `r5/1` || r2.Id != `r5/2` {
+		t.Fatalf(`last chunks should hold r5: %v`, err)
+	}
+
+	if _, err := ChunkFastq(ff, filepath.Join(dir, "chunk.fq"), nil); err == nil {
+		t.Fatalf(`ChunkFastq should fail for a pattern with no verb`)
+	}
+}
@@ -0,0 +1,173 @@
+package genome
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// TandemRepeatOptions controls which tandem repeats are reported.
+// Repeats with a unit (period) from MinPeriod to MaxPeriod bases are
+// found. A repeat is only reported if it contains at least MinCopies
+// complete copies of the unit and is at least MinLength bases long
+// including any trailing partial copy. Homopolymers are tandem repeats
+// with a period of 1.
+type TandemRepeatOptions struct {
+	MinPeriod int
+	MaxPeriod int
+	MinCopies int
+	MinLength int
+}
+
+// NewTandemRepeatOptions returns the defaults: periods 1 to 6 (i.e.
+// homopolymers and short tandem repeats), at least 3 copies and at
+// least 6 bases.
+func NewTandemRepeatOptions() *TandemRepeatOptions {
+	return &TandemRepeatOptions{MinPeriod: 1, MaxPeriod: 6, MinCopies: 3, MinLength: 6}
+}
+
+// tandemRepeat is a single repeat with 0-based half-open limits.
+type tandemRepeat struct {
+	start, end int
+	period     int
+}
+
+// tandemRepeats returns the perfect tandem repeats in seq. For each
+// period p, a repeat is a maximal run where every base equals the base
+// p positions earlier. Matching ignores case and N never matches. Runs
+// whose unit is itself a repeat of a shorter unit (e.g. AA as a period
+// 2 repeat) are skipped because they are reported at the shorter
+// period.
+func tandemRepeats(seq string, opts *TandemRepeatOptions) []tandemRepeat {
+	var reps []tandemRepeat
+	minP := opts.MinPeriod
+	if minP < 1 {
+		minP = 1
+	}
+	same := func(i, j int) bool {
+		a, b := toUpper(seq[i]), toUpper(seq[j])
+		return a == b && a != 'N'
+	}
+
+	for p := minP; p <= opts.MaxPeriod; p++ {
+		// run counts the consecutive positions that equal the base p
+		// positions earlier, so the repeat spans run+p bases.
+		run := 0
+		for i := p; i <= len(seq); i++ {
+			if i < len(seq) && same(i, i-p) {
+				run++
+				continue
+			}
+			if run > 0 {
+				start, end := i-run-p, i
+				l := end - start
+				if l/p >= opts.MinCopies && l >= opts.MinLength &&
+					isPrimitiveUnit(seq[start:start+p]) {
+					reps = append(reps, tandemRepeat{start, end, p})
+				}
+			}
+			run = 0
+		}
+	}
+
+	sort.SliceStable(reps, func(i, j int) bool {
+		if reps[i].start != reps[j].start {
+			return reps[i].start < reps[j].start
+		}
+		return reps[i].period < reps[j].period
+	})
+	return reps
+}
+
+// isPrimitiveUnit returns false if unit is a repeat of a shorter unit.
+func isPrimitiveUnit(unit string) bool {
+	n := len(unit)
+	for q := 1; q < n; q++ {
+		if n%q != 0 {
+			continue
+		}
+		repeated := true
+		for i := q; i < n; i++ {
+			if toUpper(unit[i]) != toUpper(unit[i-q]) {
+				repeated = false
+				break
+			}
+		}
+		if repeated {
+			return false
+		}
+	}
+	return true
+}
+
+// TandemRepeats finds perfect short tandem repeats and homopolymers in
+// the FastaRec and returns a gff3.Feature of Type tandem_repeat for
+// each. If opts is nil, NewTandemRepeatOptions is used. Each Feature
+// has the attributes Period, Unit (uppercase, as it first appears on
+// the plus strand) and Copies which may be fractional if the repeat
+// ends with a partial copy of the unit. Features are in start order.
+func (r *FastaRec) TandemRepeats(opts *TandemRepeatOptions) []*gff3.Feature {
+	if opts == nil {
+		opts = NewTandemRepeatOptions()
+	}
+	var feats []*gff3.Feature
+	for _, rep := range tandemRepeats(r.Sequence, opts) {
+		unit := []byte(r.Sequence[rep.start : rep.start+rep.period])
+		for i, b := range unit {
+			unit[i] = toUpper(b)
+		}
+		l := rep.end - rep.start
+		f := gff3.NewFeature()
+		f.SeqId = r.Name
+		f.Source = `grz-repeat`
+		f.Type = `tandem_repeat`
+		f.Start = rep.start + 1
+		f.End = rep.end
+		f.Attributes[`Period`] = strconv.Itoa(rep.period)
+		f.Attributes[`Unit`] = string(unit)
+		f.Attributes[`Copies`] = strconv.FormatFloat(float64(l)/float64(rep.period), 'f', 1, 64)
+		feats = append(feats, f)
+	}
+	return feats
+}
+
+// Homopolymers returns a gff3.Feature for every run of at least
+// minLength copies of the same base in the FastaRec. It is
+// TandemRepeats restricted to a period of 1.
+func (r *FastaRec) Homopolymers(minLength int) []*gff3.Feature {
+	return r.TandemRepeats(homopolymerOptions(minLength))
+}
+
+func homopolymerOptions(minLength int) *TandemRepeatOptions {
+	if minLength < 2 {
+		minLength = 2
+	}
+	return &TandemRepeatOptions{MinPeriod: 1, MaxPeriod: 1, MinCopies: minLength, MinLength: minLength}
+}
+
+// TandemRepeats finds the tandem repeats in every sequence of the
+// Genome and returns them as a sorted gff3.Features. See
+// FastaRec.TandemRepeats.
+func (g *Genome) TandemRepeats(opts *TandemRepeatOptions) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(r *FastaRec) error {
+		fs.AddFeatures(r.TandemRepeats(opts)...)
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.TandemRepeats: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
+
+// Homopolymers finds the homopolymer runs of at least minLength bases
+// in every sequence of the Genome and returns them as a sorted
+// gff3.Features.
+func (g *Genome) Homopolymers(minLength int) (*gff3.Features, error) {
+	return g.TandemRepeats(homopolymerOptions(minLength))
+}
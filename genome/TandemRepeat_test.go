@@ -0,0 +1,67 @@
+package genome
+
+import (
+	"testing"
+)
+
+func TestTandemRepeats(t *testing.T) {
+	r := NewFastaRec(">chrT")
+	r.Sequence = `GCaaaaaaaGTCACACACAGTTAGCTAGCTAGCTGNNNNNNNN`
+
+	feats := r.TandemRepeats(nil)
+	if len(feats) != 3 {
+		t.Fatalf(`TandemRepeats should find 3 repeats but found %d`, len(feats))
+	}
+	e := []struct {
+		start, end int
+		period     string
+		unit       string
+		copies     string
+	}{
+		{3, 9, `1`, `A`, `7.0`},
+		{12, 19, `2`, `CA`, `4.0`},
+		{22, 34, `4`, `TAGC`, `3.2`},
+	}
+	for i, f := range feats {
+		if f.Start != e[i].start || f.End != e[i].end ||
+			f.Attributes[`Period`] != e[i].period ||
+			f.Attributes[`Unit`] != e[i].unit ||
+			f.Attributes[`Copies`] != e[i].copies {
+			t.Fatalf(`repeat %d should be %d-%d %s %s %s but is %s`, i,
+				e[i].start, e[i].end, e[i].period, e[i].unit, e[i].copies, f.String())
+		}
+	}
+
+	// Ns are never reported as a homopolymer
+	feats = r.Homopolymers(2)
+	if len(feats) != 2 || feats[0].Start != 3 || feats[1].Start != 21 {
+		t.Fatalf(`Homopolymers(2) should find repeats at 3 and 21 but found %d`, len(feats))
+	}
+
+	opts := NewTandemRepeatOptions()
+	opts.MinCopies = 4
+	if feats = r.TandemRepeats(opts); len(feats) != 2 {
+		t.Fatalf(`TandemRepeats(MinCopies 4) should find 2 repeats but found %d`, len(feats))
+	}
+}
+
+func TestIsPrimitiveUnit(t *testing.T) {
+	tests := []struct {
+		unit string
+		e    bool
+	}{
+		{`A`, true},
+		{`AC`, true},
+		{`AA`, false},
+		{`ACAC`, false},
+		{`ACG`, true},
+		{`ACGACG`, false},
+		{`AACAAC`, false},
+		{`AAC`, true},
+	}
+	for _, tt := range tests {
+		if g := isPrimitiveUnit(tt.unit); g != tt.e {
+			t.Fatalf(`isPrimitiveUnit(%s) should be %v but is %v`, tt.unit, tt.e, g)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package genome
+
+import (
+	"fmt"
+)
+
+// The trimming methods of FastqRec remove bases from the 3' end of the
+// read, in place, and return the number of bases removed. They assume
+// Phred+33 qualities (see ConvertQualities). A Trimmer applies a series
+// of them to many reads and keeps statistics.
+
+// truncate keeps the first n bases and qualities of the read and
+// returns the number of bases removed.
+func (r *FastqRec) truncate(n int) int {
+	if n < 0 {
+		n = 0
+	}
+	if n >= len(r.Bases) {
+		return 0
+	}
+	removed := len(r.Bases) - n
+	r.Bases = r.Bases[:n]
+	if n < len(r.Qualities) {
+		r.Qualities = r.Qualities[:n]
+	}
+	return removed
+}
+
+// TrimQualityWindow slides a window of the given size along the read
+// from the 5' end and cuts the read at the start of the first window
+// whose mean quality is below minQual. Reads shorter than the window
+// are treated as a single window.
+func (r *FastqRec) TrimQualityWindow(window int, minQual float64) int {
+	n := len(r.Qualities)
+	if window < 1 || n == 0 {
+		return 0
+	}
+	if window > n {
+		window = n
+	}
+	sum := 0
+	for i := 0; i < window; i++ {
+		sum += r.Quality(i)
+	}
+	for i := 0; ; i++ {
+		if float64(sum) < minQual*float64(window) {
+			return r.truncate(i)
+		}
+		if i+window >= n {
+			return 0
+		}
+		sum += r.Quality(i+window) - r.Quality(i)
+	}
+}
+
+// TrimQualityMott trims the 3' end with the modified Mott algorithm
+// used by BWA and cutadapt: the read is cut at the position that
+// maximises the sum of (cutoff - quality) over the bases removed, so a
+// tail of mostly low quality bases is removed even if it contains a few
+// good ones.
+func (r *FastqRec) TrimQualityMott(cutoff int) int {
+	sum, best, cut := 0, 0, len(r.Qualities)
+	for i := len(r.Qualities) - 1; i >= 0; i-- {
+		sum += cutoff - r.Quality(i)
+		if sum < 0 {
+			break
+		}
+		if sum > best {
+			best, cut = sum, i
+		}
+	}
+	return r.truncate(cut)
+}
+
+// AdapterOptions controls adapter matching. At most MaxErrorRate of
+// the overlapping bases may be mismatches and the overlap must be at
+// least MinOverlap bases. N in the read or adapter matches any base.
+type AdapterOptions struct {
+	MaxErrorRate float64
+	MinOverlap   int
+}
+
+// NewAdapterOptions returns the options used by cutadapt for 3'
+// adapters: a 10% error rate and a minimum overlap of 3.
+func NewAdapterOptions() *AdapterOptions {
+	return &AdapterOptions{MaxErrorRate: 0.1, MinOverlap: 3}
+}
+
+// NewPairAdapterOptions returns options for TrimAdapterPair: a 10%
+// error rate and a minimum overlap of 30 so that chance overlaps
+// between unrelated reads are very unlikely.
+func NewPairAdapterOptions() *AdapterOptions {
+	return &AdapterOptions{MaxErrorRate: 0.1, MinOverlap: 30}
+}
+
+// overlapMatches returns true if a and b match with no more than the
+// allowed mismatches. They must be the same length.
+func overlapMatches(a, b []byte, maxErrorRate float64) bool {
+	allowed := int(float64(len(a)) * maxErrorRate)
+	mm := 0
+	for i := range a {
+		x, y := toUpper(a[i]), toUpper(b[i])
+		if x != y && x != 'N' && y != 'N' {
+			mm++
+			if mm > allowed {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TrimAdapter removes a 3' adapter and everything after it. The
+// adapter may be anywhere in the read or partly off the 3' end as long
+// as at least MinOverlap bases of it are in the read. The leftmost
+// match is used. Only mismatches are allowed, not insertions or
+// deletions. If opts is nil, NewAdapterOptions is used.
+func (r *FastqRec) TrimAdapter(adapter string, opts *AdapterOptions) int {
+	if opts == nil {
+		opts = NewAdapterOptions()
+	}
+	a := []byte(adapter)
+	for i := 0; i < len(r.Bases); i++ {
+		overlap := len(r.Bases) - i
+		if overlap > len(a) {
+			overlap = len(a)
+		}
+		if overlap < opts.MinOverlap {
+			break
+		}
+		if overlapMatches(r.Bases[i:i+overlap], a[:overlap], opts.MaxErrorRate) {
+			return r.truncate(i)
+		}
+	}
+	return 0
+}
+
+// TrimAdapterPair finds adapters in a read pair without knowing the
+// adapter sequences, as fastp does. If the insert is shorter than the
+// reads, the start of R1 is the reverse complement of the start of R2
+// and both reads continue into adapter. The longest such overlap of at
+// least MinOverlap bases is found and both reads are cut to the insert
+// length. It returns the total number of bases removed from both
+// reads. If opts is nil, NewPairAdapterOptions is used.
+func TrimAdapterPair(r1, r2 *FastqRec, opts *AdapterOptions) int {
+	if opts == nil {
+		opts = NewPairAdapterOptions()
+	}
+	rc2 := reverseComplement(r2.Bases)
+	longest := len(r1.Bases)
+	if len(rc2) < longest {
+		longest = len(rc2)
+	}
+	for l := longest; l >= opts.MinOverlap; l-- {
+		if overlapMatches(r1.Bases[:l], rc2[len(rc2)-l:], opts.MaxErrorRate) {
+			return r1.truncate(l) + r2.truncate(l)
+		}
+	}
+	return 0
+}
+
+// TrimPolyX removes a 3' tail of the given base, for example the
+// poly-G that 2-colour Illumina instruments call when there is no
+// signal, or a poly-A tail. The tail must be at least minLength bases
+// long, start with the base and may contain one mismatch in every 8
+// bases up to a maximum of 5, as in fastp.
+func (r *FastqRec) TrimPolyX(base byte, minLength int) int {
+	base = toUpper(base)
+	mm, cut := 0, len(r.Bases)
+	for i := len(r.Bases) - 1; i >= 0; i-- {
+		if toUpper(r.Bases[i]) != base {
+			mm++
+		}
+		l := len(r.Bases) - i
+		if mm > 5 || (l >= minLength && mm > l/8) {
+			break
+		}
+		if toUpper(r.Bases[i]) == base {
+			cut = i
+		}
+	}
+	if len(r.Bases)-cut < minLength {
+		return 0
+	}
+	return r.truncate(cut)
+}
+
+// TrimStats counts what one trimming step did. Discarded is only used
+// by the minimum length filter.
+type TrimStats struct {
+	Name      string
+	Reads     int
+	Trimmed   int
+	Bases     int
+	Discarded int
+}
+
+func (s *TrimStats) add(removed int) {
+	s.Reads++
+	if removed > 0 {
+		s.Trimmed++
+		s.Bases += removed
+	}
+}
+
+// String returns the statistics as tab-separated name, reads, reads
+// trimmed, bases removed and reads discarded.
+func (s *TrimStats) String() string {
+	return fmt.Sprintf("%s\t%d\t%d\t%d\t%d", s.Name, s.Reads, s.Trimmed, s.Bases, s.Discarded)
+}
+
+type trimStep struct {
+	stats *TrimStats
+	trim  func(*FastqRec) int
+}
+
+// Trimmer applies trimming steps to reads, in the order they were
+// added, and then discards reads shorter than MinLength.
+type Trimmer struct {
+	MinLength int
+	pair      *AdapterOptions
+	pairStats *TrimStats
+	steps     []trimStep
+	length    *TrimStats
+}
+
+// NewTrimmer returns a Trimmer with no steps that discards reads
+// shorter than minLength after trimming.
+func NewTrimmer(minLength int) *Trimmer {
+	return &Trimmer{MinLength: minLength,
+		length: &TrimStats{Name: `min_length`}}
+}
+
+func (t *Trimmer) addStep(name string, trim func(*FastqRec) int) {
+	t.steps = append(t.steps, trimStep{&TrimStats{Name: name}, trim})
+}
+
+// AddQualityWindow adds a TrimQualityWindow step.
+func (t *Trimmer) AddQualityWindow(window int, minQual float64) {
+	t.addStep(`quality_window`, func(r *FastqRec) int {
+		return r.TrimQualityWindow(window, minQual)
+	})
+}
+
+// AddQualityMott adds a TrimQualityMott step.
+func (t *Trimmer) AddQualityMott(cutoff int) {
+	t.addStep(`quality_mott`, func(r *FastqRec) int {
+		return r.TrimQualityMott(cutoff)
+	})
+}
+
+// AddAdapter adds a TrimAdapter step.
+func (t *Trimmer) AddAdapter(adapter string, opts *AdapterOptions) {
+	t.addStep(`adapter_`+adapter, func(r *FastqRec) int {
+		return r.TrimAdapter(adapter, opts)
+	})
+}
+
+// AddPolyX adds a TrimPolyX step.
+func (t *Trimmer) AddPolyX(base byte, minLength int) {
+	t.addStep(`poly`+string(toUpper(base)), func(r *FastqRec) int {
+		return r.TrimPolyX(base, minLength)
+	})
+}
+
+// SetPairOverlap makes TrimPair start with TrimAdapterPair.
+func (t *Trimmer) SetPairOverlap(opts *AdapterOptions) {
+	if opts == nil {
+		opts = NewPairAdapterOptions()
+	}
+	t.pair = opts
+	t.pairStats = &TrimStats{Name: `pair_overlap`}
+}
+
+// Trim applies every step to the read and returns false if the read
+// should be discarded because it is now shorter than MinLength.
+func (t *Trimmer) Trim(r *FastqRec) bool {
+	for _, s := range t.steps {
+		s.stats.add(s.trim(r))
+	}
+	t.length.Reads++
+	if len(r.Bases) < t.MinLength {
+		t.length.Discarded++
+		return false
+	}
+	return true
+}
+
+// TrimPair trims both reads of a pair and returns false if the pair
+// should be discarded because either read is now shorter than
+// MinLength. Read statistics count each read of the pair.
+func (t *Trimmer) TrimPair(r1, r2 *FastqRec) bool {
+	if t.pair != nil {
+		l1, l2 := len(r1.Bases), len(r2.Bases)
+		TrimAdapterPair(r1, r2, t.pair)
+		t.pairStats.add(l1 - len(r1.Bases))
+		t.pairStats.add(l2 - len(r2.Bases))
+	}
+	ok1 := t.Trim(r1)
+	ok2 := t.Trim(r2)
+	return ok1 && ok2
+}
+
+// Stats returns the statistics of each step in the order they are
+// applied, ending with the minimum length filter.
+func (t *Trimmer) Stats() []*TrimStats {
+	var stats []*TrimStats
+	if t.pairStats != nil {
+		stats = append(stats, t.pairStats)
+	}
+	for _, s := range t.steps {
+		stats = append(stats, s.stats)
+	}
+	return append(stats, t.length)
+}
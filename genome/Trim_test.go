@@ -0,0 +1,145 @@
+package genome
+
+import (
+	"strings"
+	"testing"
+)
+
+func trimRec(bases, quals string) *FastqRec {
+	r := NewFastqRec()
+	r.Id = `t`
+	if quals == `` {
+		quals = strings.Repeat(`I`, len(bases))
+	}
+	r.SetBasesFromString(bases)
+	r.SetQualitiesFromString(quals)
+	return r
+}
+
+func TestTrimQuality(t *testing.T) {
+	// Q40 x6, Q2 x4 then Q40 x2
+	r := trimRec(`ACGTACGTACGT`, `IIIIII####II`)
+	if n := r.TrimQualityWindow(4, 20); n != 7 || string(r.Bases) != `ACGTA` {
+		t.Fatalf(`TrimQualityWindow removed %d leaving %s`, n, r.Bases)
+	}
+	if len(r.Qualities) != len(r.Bases) {
+		t.Fatalf(`TrimQualityWindow left %d qualities for %d bases`, len(r.Qualities), len(r.Bases))
+	}
+	r = trimRec(`ACGTACGTACGT`, `IIIIIIIIIIII`)
+	if n := r.TrimQualityWindow(4, 20); n != 0 {
+		t.Fatalf(`TrimQualityWindow should not trim a good read but removed %d`, n)
+	}
+
+	// Mott removes a good base in a bad tail: with cutoff 20 the sums
+	// from the 3' end are 18, 36, 16, 34, 52, 70 then fall
+	r = trimRec(`ACGTACGTACGT`, `IIIIII###I##`)
+	if n := r.TrimQualityMott(20); n != 6 || string(r.Bases) != `ACGTAC` {
+		t.Fatalf(`TrimQualityMott removed %d leaving %s`, n, r.Bases)
+	}
+	r = trimRec(`ACGT`, `IIII`)
+	if n := r.TrimQualityMott(20); n != 0 {
+		t.Fatalf(`TrimQualityMott should not trim a good read but removed %d`, n)
+	}
+}
+
+func TestTrimAdapter(t *testing.T) {
+	adapter := `AGATCGGAAGAGC`
+	tests := []struct {
+		bases string
+		want  string
+	}{
+		{`TTTTTTTTTTAGATCGGAAGAGCACAC`, `TTTTTTTTTT`}, // whole adapter
+		{`TTTTTTTTTTAGATCGGTAGAGCACAC`, `TTTTTTTTTT`}, // 1 mismatch
+		{`TTTTTTTTTTAGATCGTTTTAGCACAC`, `TTTTTTTTTTAGATCGTTTTAGCACAC`},
+		{`TTTTTTTTTTTTTTTTTTTTAGATC`, `TTTTTTTTTTTTTTTTTTTT`},  // partial at 3' end
+		{`TTTTTTTTTTTTTTTTTTTTTAG`, `TTTTTTTTTTTTTTTTTTTTTAG`}, // below MinOverlap
+		{`AGATCGGAAGAGC`, ``},
+	}
+	for _, tt := range tests {
+		r := trimRec(tt.bases, ``)
+		n := r.TrimAdapter(adapter, nil)
+		if string(r.Bases) != tt.want || n != len(tt.bases)-len(tt.want) {
+			t.Fatalf(`TrimAdapter(%s) removed %d leaving %s not %s`, tt.bases, n, r.Bases, tt.want)
+		}
+	}
+}
+
+func TestTrimAdapterPair(t *testing.T) {
+	insert := randomSequence(7, 40)
+	a1 := `AGATCGGAAGAGCACACGTCTGAACTCCAGTCA`
+	a2 := `AGATCGGAAGAGCGTCGTGTAGGGAAAGAGTGT`
+	r1 := trimRec(insert+a1[:20], ``)
+	r2 := trimRec(string(reverseComplement([]byte(insert)))+a2[:20], ``)
+	if n := TrimAdapterPair(r1, r2, nil); n != 40 {
+		t.Fatalf(`TrimAdapterPair should remove 40 bases but removed %d`, n)
+	}
+	if string(r1.Bases) != insert || string(r2.Bases) != string(reverseComplement([]byte(insert))) {
+		t.Fatalf(`TrimAdapterPair should leave the insert but left %s %s`, r1.Bases, r2.Bases)
+	}
+
+	// Insert longer than the reads
+	long := randomSequence(8, 150)
+	r1 = trimRec(long[:60], ``)
+	r2 = trimRec(string(reverseComplement([]byte(long)))[:60], ``)
+	if n := TrimAdapterPair(r1, r2, nil); n != 0 {
+		t.Fatalf(`TrimAdapterPair should not trim a long insert but removed %d`, n)
+	}
+}
+
+func TestTrimPolyX(t *testing.T) {
+	tests := []struct {
+		bases string
+		want  string
+	}{
+		{`ACGTACGTACGGGGGGGGGGGG`, `ACGTACGTAC`},
+		{`ACGTACGTACGGGGGGAGGGGGGG`, `ACGTACGTAC`}, // 1 mismatch in 14
+		{`ACGTACGTACTGGGGG`, `ACGTACGTACTGGGGG`},   // too short
+		{`ACGTACGTACGT`, `ACGTACGTACGT`},
+		{`GGGGGGGGGGGG`, ``},
+	}
+	for _, tt := range tests {
+		r := trimRec(tt.bases, ``)
+		r.TrimPolyX('g', 10)
+		if string(r.Bases) != tt.want {
+			t.Fatalf(`TrimPolyX(%s) should leave %s but left %s`, tt.bases, tt.want, r.Bases)
+		}
+	}
+}
+
+func TestTrimmer(t *testing.T) {
+	tr := NewTrimmer(8)
+	tr.AddAdapter(`AGATCGGAAGAGC`, nil)
+	tr.AddPolyX('G', 10)
+	tr.AddQualityMott(20)
+
+	reads := []*FastqRec{
+		trimRec(`TTTTTTTTTTAGATCGGAAGAGC`, ``),
+		trimRec(`ACGTAGGGGGGGGGGGGG`, ``),
+		trimRec(`ACGTACGTACGT`, `IIIIIIIIII##`),
+		trimRec(`ACGTACGTACGT`, ``),
+	}
+	kept := 0
+	for _, r := range reads {
+		if tr.Trim(r) {
+			kept++
+		}
+	}
+	if kept != 3 {
+		t.Fatalf(`Trimmer should keep 3 reads but kept %d`, kept)
+	}
+	want := []string{
+		"adapter_AGATCGGAAGAGC\t4\t1\t13\t0",
+		"polyG\t4\t1\t13\t0",
+		"quality_mott\t4\t1\t2\t0",
+		"min_length\t4\t0\t0\t1",
+	}
+	stats := tr.Stats()
+	if len(stats) != len(want) {
+		t.Fatalf(`Stats should have %d steps but has %d`, len(want), len(stats))
+	}
+	for i, s := range stats {
+		if s.String() != want[i] {
+			t.Fatalf(`Stats %d should be %q but is %q`, i, want[i], s.String())
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package genome
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// The UCSC .2bit format packs 4 bases into each byte with runs of N
+// and runs of lowercase (soft-masked) bases stored separately as
+// blocks. See https://genome.ucsc.edu/FAQ/FAQformat.html#format7
+// Version 1 files, which use 64-bit offsets, can be read but only
+// version 0 files are written.
+
+const twoBitSignature = 0x1A412743
+
+// twoBitBases maps a 2-bit code to its base.
+var twoBitBases = [4]byte{'T', 'C', 'A', 'G'}
+
+// twoBitRecord holds the per-sequence header from a .2bit file. The
+// blocks are loaded on first use.
+type twoBitRecord struct {
+	name       string
+	offset     int64
+	length     int
+	loaded     bool
+	nBlocks    [][2]int // 0-based half-open
+	maskBlocks [][2]int // 0-based half-open
+	dnaOffset  int64
+}
+
+// TwoBit gives random access to the sequences in a UCSC .2bit file. It
+// is a SequenceSource.
+type TwoBit struct {
+	Filepath string
+	file     *os.File
+	order    binary.ByteOrder
+	records  []*twoBitRecord
+	index    map[string]*twoBitRecord
+	mu       sync.Mutex // guards loading of twoBitRecord blocks
+}
+
+// OpenTwoBit opens a .2bit file and reads the sequence index.
+func OpenTwoBit(file string) (*TwoBit, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("genome.OpenTwoBit: %w", err)
+	}
+	tb := &TwoBit{Filepath: file, file: f, index: make(map[string]*twoBitRecord)}
+	if err := tb.readIndex(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("genome.OpenTwoBit: %s: %w", file, err)
+	}
+	return tb, nil
+}
+
+func (tb *TwoBit) readIndex() error {
+	r := bufio.NewReader(io.NewSectionReader(tb.file, 0, 1<<62))
+
+	var hdr [16]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return err
+	}
+	switch {
+	case binary.LittleEndian.Uint32(hdr[0:]) == twoBitSignature:
+		tb.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(hdr[0:]) == twoBitSignature:
+		tb.order = binary.BigEndian
+	default:
+		return fmt.Errorf("not a .2bit file")
+	}
+	version := tb.order.Uint32(hdr[4:])
+	if version > 1 {
+		return fmt.Errorf("unsupported .2bit version %d", version)
+	}
+	count := int(tb.order.Uint32(hdr[8:]))
+
+	for i := 0; i < count; i++ {
+		size, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		name := make([]byte, size)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return err
+		}
+		rec := &twoBitRecord{name: string(name)}
+		if version == 0 {
+			var b [4]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return err
+			}
+			rec.offset = int64(tb.order.Uint32(b[:]))
+		} else {
+			var b [8]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return err
+			}
+			rec.offset = int64(tb.order.Uint64(b[:]))
+		}
+		var b [4]byte
+		if _, err := tb.file.ReadAt(b[:], rec.offset); err != nil {
+			return err
+		}
+		rec.length = int(tb.order.Uint32(b[:]))
+		tb.records = append(tb.records, rec)
+		tb.index[rec.name] = rec
+	}
+	return nil
+}
+
+// load reads the N and mask blocks for a record.
+func (tb *TwoBit) load(rec *twoBitRecord) error {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if rec.loaded {
+		return nil
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(tb.file, rec.offset+4, 1<<62))
+	read := func() (int, error) {
+		var b [4]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		return int(tb.order.Uint32(b[:])), nil
+	}
+	blocks := func() ([][2]int, int64, error) {
+		n, err := read()
+		if err != nil {
+			return nil, 0, err
+		}
+		starts := make([]int, n)
+		for i := range starts {
+			if starts[i], err = read(); err != nil {
+				return nil, 0, err
+			}
+		}
+		bs := make([][2]int, n)
+		for i := range bs {
+			size, err := read()
+			if err != nil {
+				return nil, 0, err
+			}
+			bs[i] = [2]int{starts[i], starts[i] + size}
+		}
+		return bs, int64(4 + 8*n), nil
+	}
+
+	nb, nlen, err := blocks()
+	if err != nil {
+		return err
+	}
+	mb, mlen, err := blocks()
+	if err != nil {
+		return err
+	}
+	rec.nBlocks, rec.maskBlocks = nb, mb
+	// dnaSize, blocks and a reserved uint32 precede the packed bases
+	rec.dnaOffset = rec.offset + 4 + nlen + mlen + 4
+	rec.loaded = true
+	return nil
+}
+
+// Names returns the sequence names in file order.
+func (tb *TwoBit) Names() []string {
+	var names []string
+	for _, r := range tb.records {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// Length returns the length of the named sequence.
+func (tb *TwoBit) Length(name string) (int, error) {
+	r, ok := tb.index[name]
+	if !ok {
+		return 0, fmt.Errorf("genome.TwoBit.Length: sequence %s not found in %s", name, tb.Filepath)
+	}
+	return r.length, nil
+}
+
+// Fetch returns the bases from start to end, a 0-based half-open
+// interval, of the named sequence. Soft-masked bases are lowercase.
+func (tb *TwoBit) Fetch(name string, start, end int) (string, error) {
+	rec, ok := tb.index[name]
+	if !ok {
+		return ``, fmt.Errorf("genome.TwoBit.Fetch: sequence %s not found in %s", name, tb.Filepath)
+	}
+	if start < 0 || end > rec.length || start > end {
+		return ``, fmt.Errorf("genome.TwoBit.Fetch: %d-%d is outside %s (0-%d)", start, end, name, rec.length)
+	}
+	if err := tb.load(rec); err != nil {
+		return ``, fmt.Errorf("genome.TwoBit.Fetch: reading %s: %w", tb.Filepath, err)
+	}
+	if start == end {
+		return ``, nil
+	}
+
+	packed := make([]byte, (end-1)/4-start/4+1)
+	if _, err := tb.file.ReadAt(packed, rec.dnaOffset+int64(start/4)); err != nil {
+		return ``, fmt.Errorf("genome.TwoBit.Fetch: reading %s: %w", tb.Filepath, err)
+	}
+	seq := make([]byte, end-start)
+	for i := start; i < end; i++ {
+		b := packed[i/4-start/4]
+		seq[i-start] = twoBitBases[(b>>(6-2*(i%4)))&3]
+	}
+
+	apply := func(blocks [][2]int, f func(byte) byte) {
+		// Blocks are sorted so skip those that end before start
+		j := sort.Search(len(blocks), func(k int) bool { return blocks[k][1] > start })
+		for ; j < len(blocks) && blocks[j][0] < end; j++ {
+			from, to := blocks[j][0], blocks[j][1]
+			if from < start {
+				from = start
+			}
+			if to > end {
+				to = end
+			}
+			for k := from; k < to; k++ {
+				seq[k-start] = f(seq[k-start])
+			}
+		}
+	}
+	apply(rec.nBlocks, func(byte) byte { return 'N' })
+	apply(rec.maskBlocks, func(b byte) byte {
+		if b >= 'A' && b <= 'Z' {
+			return b + 'a' - 'A'
+		}
+		return b
+	})
+	return string(seq), nil
+}
+
+// Close closes the .2bit file.
+func (tb *TwoBit) Close() error {
+	return tb.file.Close()
+}
+
+// WriteTwoBit writes the FastaRec to file in .2bit (version 0) format.
+// Bases other than A, C, G and T are stored as N and lowercase bases
+// are recorded as soft-masked.
+func WriteTwoBit(file string, recs []*FastaRec) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	le := binary.LittleEndian
+	put := func(v uint32) error {
+		var b [4]byte
+		le.PutUint32(b[:], v)
+		_, err := w.Write(b[:])
+		return err
+	}
+
+	// Header and index
+	for _, v := range []uint32{twoBitSignature, 0, uint32(len(recs)), 0} {
+		if err := put(v); err != nil {
+			return err
+		}
+	}
+	offset := 16
+	for _, r := range recs {
+		if len(r.Name) > 255 {
+			return fmt.Errorf("genome.WriteTwoBit: sequence name %s is too long", r.Name)
+		}
+		offset += 1 + len(r.Name) + 4
+	}
+	for _, r := range recs {
+		if err := w.WriteByte(byte(len(r.Name))); err != nil {
+			return err
+		}
+		if _, err := w.WriteString(r.Name); err != nil {
+			return err
+		}
+		if err := put(uint32(offset)); err != nil {
+			return err
+		}
+		nb := len(nRuns2bit(r.Sequence))
+		mb := len(byteRuns(r.Sequence, 1, isSoftMasked))
+		offset += 4 + 4 + 8*nb + 4 + 8*mb + 4 + (len(r.Sequence)+3)/4
+		if offset > 1<<32-1 {
+			return fmt.Errorf("genome.WriteTwoBit: sequences are too long for a version 0 .2bit file")
+		}
+	}
+
+	// Sequence records
+	for _, r := range recs {
+		if err := put(uint32(len(r.Sequence))); err != nil {
+			return err
+		}
+		for _, blocks := range [][][2]int{nRuns2bit(r.Sequence), byteRuns(r.Sequence, 1, isSoftMasked)} {
+			if err := put(uint32(len(blocks))); err != nil {
+				return err
+			}
+			for _, b := range blocks {
+				if err := put(uint32(b[0])); err != nil {
+					return err
+				}
+			}
+			for _, b := range blocks {
+				if err := put(uint32(b[1] - b[0])); err != nil {
+					return err
+				}
+			}
+		}
+		if err := put(0); err != nil {
+			return err
+		}
+		packed := make([]byte, (len(r.Sequence)+3)/4)
+		for i := 0; i < len(r.Sequence); i++ {
+			var code byte // T and anything that is not A, C or G
+			switch toUpper(r.Sequence[i]) {
+			case 'C':
+				code = 1
+			case 'A':
+				code = 2
+			case 'G':
+				code = 3
+			}
+			packed[i/4] |= code << (6 - 2*(i%4))
+		}
+		if _, err := w.Write(packed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nRuns2bit returns the runs of bases that .2bit stores as N, i.e.
+// anything other than A, C, G or T.
+func nRuns2bit(seq string) [][2]int {
+	return byteRuns(seq, 1, func(b byte) bool {
+		return nt4(b) > 3
+	})
+}
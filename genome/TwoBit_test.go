@@ -0,0 +1,59 @@
+package genome
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTwoBit(t *testing.T) {
+	r1 := NewFastaRec(`>chr1`)
+	r1.Sequence = `ACGTACGTACGTacgtNNNNACG`
+	r2 := NewFastaRec(`>chr2`)
+	r2.Sequence = `nnTTRYGGaaCC`
+	r3 := NewFastaRec(`>empty`)
+
+	file := filepath.Join(t.TempDir(), "test.2bit")
+	if err := WriteTwoBit(file, []*FastaRec{r1, r2, r3}); err != nil {
+		t.Fatalf(`WriteTwoBit failed: %v`, err)
+	}
+	tb, err := OpenTwoBit(file)
+	if err != nil {
+		t.Fatalf(`OpenTwoBit failed: %v`, err)
+	}
+	defer tb.Close()
+
+	names := tb.Names()
+	if len(names) != 3 || names[1] != `chr2` {
+		t.Fatalf(`TwoBit.Names incorrect: %v`, names)
+	}
+	if l, _ := tb.Length(`chr2`); l != 12 {
+		t.Fatalf(`TwoBit.Length(chr2) should be 12 but is %d`, l)
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+		e          string
+	}{
+		{`chr1`, 0, 23, r1.Sequence},
+		{`chr1`, 5, 18, `CGTACGTacgtNN`},
+		{`chr1`, 22, 23, `G`},
+		// IUPAC codes are stored as N
+		{`chr2`, 0, 12, `nnTTNNGGaaCC`},
+		{`chr2`, 7, 9, `Ga`},
+		{`empty`, 0, 0, ``},
+	}
+	for _, tt := range tests {
+		g, err := tb.Fetch(tt.name, tt.start, tt.end)
+		if err != nil {
+			t.Fatalf(`Fetch(%s,%d,%d) failed: %v`, tt.name, tt.start, tt.end, err)
+		}
+		if g != tt.e {
+			t.Fatalf(`Fetch(%s,%d,%d) should be %s but is %s`, tt.name, tt.start, tt.end, tt.e, g)
+		}
+	}
+
+	if _, err := tb.Fetch(`chr2`, 10, 13); err == nil {
+		t.Fatalf(`Fetch beyond the end of a sequence should fail`)
+	}
+}
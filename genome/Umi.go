@@ -0,0 +1,178 @@
+package genome
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExtractUmi removes a UMI from the 5' end of the read and adds it to
+// the read name with SetUmi. The pattern has one character per base:
+// N for a UMI base and X for a base that is removed but not kept, for
+// example NNNNNNNNXX for an 8 base UMI followed by 2 spacer bases. The
+// bases after the pattern are the new read. It returns the UMI. It is
+// an error for the pattern to contain other characters or to be longer
+// than the read.
+func (r *FastqRec) ExtractUmi(pattern string) (string, error) {
+	if pattern == `` || strings.Trim(strings.ToUpper(pattern), `NX`) != `` {
+		return ``, fmt.Errorf("genome.FastqRec.ExtractUmi: invalid pattern %q", pattern)
+	}
+	if len(pattern) > len(r.Bases) || len(pattern) > len(r.Qualities) {
+		return ``, fmt.Errorf("genome.FastqRec.ExtractUmi: read %s is shorter than pattern %s", r.Id, pattern)
+	}
+	var umi []byte
+	for i := 0; i < len(pattern); i++ {
+		if toUpper(pattern[i]) == 'N' {
+			umi = append(umi, toUpper(r.Bases[i]))
+		}
+	}
+	r.Bases = r.Bases[len(pattern):]
+	r.Qualities = r.Qualities[len(pattern):]
+	r.SetUmi(string(umi))
+	return string(umi), nil
+}
+
+// SetUmi adds the UMI to the end of the read name (the Id up to the
+// first space) after a colon, as bcl2fastq does, so the Id
+// A00123:8:H7KJ2DSXX:1:1101:10004:10019 1:N:0:ACGT becomes
+// A00123:8:H7KJ2DSXX:1:1101:10004:10019:GATCTTAC 1:N:0:ACGT and
+// ReadHeader.UMI is set when it is parsed. Use this to add the UMI
+// extracted from R1 to R2.
+func (r *FastqRec) SetUmi(umi string) {
+	name, comment, found := strings.Cut(r.Id, ` `)
+	r.Id = name + `:` + umi
+	if found {
+		r.Id += ` ` + comment
+	}
+}
+
+// Umi returns the UMI added to the read name by SetUmi or bcl2fastq,
+// or an empty string if the last colon-separated field of the name is
+// not made up of the bases ACGTN (and + between the UMIs of dual UMI
+// reads).
+func (r *FastqRec) Umi() string {
+	name, _, _ := strings.Cut(r.Id, ` `)
+	i := strings.LastIndex(name, `:`)
+	if i < 0 || i == len(name)-1 {
+		return ``
+	}
+	umi := name[i+1:]
+	if strings.Trim(umi, `ACGTN+`) != `` {
+		return ``
+	}
+	return umi
+}
+
+// UmiFamily is a group of UMIs that are taken to come from the same
+// original molecule. Umi is the most common UMI in the family and
+// Count is the number of reads with any of the Members, which includes
+// Umi.
+type UmiFamily struct {
+	Umi     string
+	Count   int
+	Members []string
+}
+
+// ClusterUmis groups UMIs with the directional adjacency method of
+// UMI-tools. UMI a is connected to UMI b if they differ by at most
+// maxDistance bases and count(a) >= 2*count(b) - 1, on the basis that
+// b is then likely to be a sequencing or PCR error of a. The distance
+// is the Hamming distance so only UMIs of the same length are
+// connected and N always counts as a difference. Starting from the
+// most common UMI, each UMI not already in a family starts a new
+// family that takes every unassigned UMI reachable from it. Families
+// are returned with the largest Count first. Every pair of UMIs is
+// compared so counts should be for the reads at one position or
+// other small group.
+func ClusterUmis(counts map[string]int, maxDistance int) []*UmiFamily {
+	umis := make([]string, 0, len(counts))
+	for u := range counts {
+		umis = append(umis, u)
+	}
+	sort.Slice(umis, func(i, j int) bool {
+		if counts[umis[i]] != counts[umis[j]] {
+			return counts[umis[i]] > counts[umis[j]]
+		}
+		return umis[i] < umis[j]
+	})
+
+	// Directed edges
+	edges := make([][]int, len(umis))
+	for i, a := range umis {
+		for j, b := range umis {
+			if i == j || len(a) != len(b) {
+				continue
+			}
+			if counts[a] >= 2*counts[b]-1 && hamming(a, b) <= maxDistance {
+				edges[i] = append(edges[i], j)
+			}
+		}
+	}
+
+	var families []*UmiFamily
+	assigned := make([]bool, len(umis))
+	for i := range umis {
+		if assigned[i] {
+			continue
+		}
+		f := &UmiFamily{Umi: umis[i]}
+		assigned[i] = true
+		queue := []int{i}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			f.Members = append(f.Members, umis[n])
+			f.Count += counts[umis[n]]
+			for _, m := range edges[n] {
+				if !assigned[m] {
+					assigned[m] = true
+					queue = append(queue, m)
+				}
+			}
+		}
+		families = append(families, f)
+	}
+	sort.SliceStable(families, func(i, j int) bool {
+		return families[i].Count > families[j].Count
+	})
+	return families
+}
+
+// ReadFamily is the reads of a UmiFamily. The first read is the one
+// to keep when marking duplicates; the rest are duplicates of it.
+type ReadFamily struct {
+	Umi   string
+	Reads []*FastqRec
+}
+
+// GroupByUmi groups reads into families by clustering their UMIs (see
+// FastqRec.Umi) with ClusterUmis. Reads should already be grouped by
+// anything else that must match for reads to be duplicates, for
+// example alignment position. Within each family the reads keep their
+// input order. It is an error for a read to have no UMI.
+func GroupByUmi(reads []*FastqRec, maxDistance int) ([]*ReadFamily, error) {
+	counts := make(map[string]int)
+	for _, r := range reads {
+		u := r.Umi()
+		if u == `` {
+			return nil, fmt.Errorf("genome.GroupByUmi: read %s has no UMI", r.Id)
+		}
+		counts[u]++
+	}
+
+	families := ClusterUmis(counts, maxDistance)
+	family := make(map[string]*ReadFamily)
+	var rfs []*ReadFamily
+	for _, f := range families {
+		rf := &ReadFamily{Umi: f.Umi}
+		rfs = append(rfs, rf)
+		for _, m := range f.Members {
+			family[m] = rf
+		}
+	}
+	for _, r := range reads {
+		rf := family[r.Umi()]
+		rf.Reads = append(rf.Reads, r)
+	}
+	return rfs, nil
+}
@@ -0,0 +1,86 @@
+package genome
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExtractUmi(t *testing.T) {
+	r := trimRec(`GATCTTACTGACGTACGT`, `ABCDEFGHIJKLMNOPQR`)
+	r.Id = `A00123:8:H7KJ2DSXX:1:1101:10004:10019 1:N:0:ACGT`
+	umi, err := r.ExtractUmi(`NNNNNNNNXX`)
+	if err != nil {
+		t.Fatalf(`ExtractUmi failed: %v`, err)
+	}
+	if umi != `GATCTTAC` || string(r.Bases) != `ACGTACGT` || string(r.Qualities) != `KLMNOPQR` {
+		t.Fatalf(`ExtractUmi gave %s leaving %s %s`, umi, r.Bases, r.Qualities)
+	}
+	if r.Id != `A00123:8:H7KJ2DSXX:1:1101:10004:10019:GATCTTAC 1:N:0:ACGT` {
+		t.Fatalf(`ExtractUmi Id incorrect: %s`, r.Id)
+	}
+	if r.Umi() != `GATCTTAC` {
+		t.Fatalf(`Umi should be GATCTTAC but is %s`, r.Umi())
+	}
+	h, err := r.Header()
+	if err != nil || h.UMI != `GATCTTAC` || h.Y != 10019 {
+		t.Fatalf(`Header should parse the UMI: %v`, err)
+	}
+
+	r2 := trimRec(`ACGT`, ``)
+	r2.Id = `read1`
+	r2.SetUmi(umi)
+	if r2.Id != `read1:GATCTTAC` {
+		t.Fatalf(`SetUmi Id incorrect: %s`, r2.Id)
+	}
+	for _, p := range []string{`NNNNNX`, `NNA`, ``} {
+		if _, err := r2.ExtractUmi(p); err == nil {
+			t.Fatalf(`ExtractUmi(%s) should fail`, p)
+		}
+	}
+
+	r2.Id = `read1 comment`
+	if r2.Umi() != `` {
+		t.Fatalf(`read1 should have no UMI but has %s`, r2.Umi())
+	}
+}
+
+func TestClusterUmis(t *testing.T) {
+	counts := map[string]int{`AAAA`: 10, `AAAT`: 4, `AATT`: 2, `CCCC`: 5, `CCCG`: 6, `AAA`: 1}
+	families := ClusterUmis(counts, 1)
+	want := []string{`AAAA:16:AAAA,AAAT,AATT`, `CCCG:6:CCCG`, `CCCC:5:CCCC`, `AAA:1:AAA`}
+	if len(families) != len(want) {
+		t.Fatalf(`ClusterUmis should give %d families but gave %d`, len(want), len(families))
+	}
+	for i, f := range families {
+		got := fmt.Sprintf("%s:%d:%s", f.Umi, f.Count, strings.Join(f.Members, `,`))
+		if got != want[i] {
+			t.Fatalf(`family %d should be %s but is %s`, i, want[i], got)
+		}
+	}
+}
+
+func TestGroupByUmi(t *testing.T) {
+	var reads []*FastqRec
+	for i, u := range []string{`AAAA`, `CCCC`, `AAAT`, `AAAA`, `CCCC`, `AAAA`} {
+		r := trimRec(`ACGT`, ``)
+		r.Id = fmt.Sprintf("r%d", i+1)
+		r.SetUmi(u)
+		reads = append(reads, r)
+	}
+	rfs, err := GroupByUmi(reads, 1)
+	if err != nil {
+		t.Fatalf(`GroupByUmi failed: %v`, err)
+	}
+	if len(rfs) != 2 || rfs[0].Umi != `AAAA` || len(rfs[0].Reads) != 4 || len(rfs[1].Reads) != 2 {
+		t.Fatalf(`GroupByUmi should give AAAA with 4 reads and CCCC with 2`)
+	}
+	if rfs[0].Reads[0].Id != `r1:AAAA` || rfs[0].Reads[1].Id != `r3:AAAT` {
+		t.Fatalf(`GroupByUmi should keep input order`)
+	}
+
+	reads[0].Id = `r1`
+	if _, err := GroupByUmi(reads, 1); err == nil {
+		t.Fatalf(`GroupByUmi should fail for a read with no UMI`)
+	}
+}
@@ -0,0 +1,205 @@
+package genome
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/grendeloz/ngs/gff3"
+)
+
+// WindowOptions controls how sequences are tiled into windows. Windows
+// are Size bases long and start every Step bases so a Step equal to
+// Size gives a non-overlapping tiling and a smaller Step gives sliding
+// windows. The last window of a sequence is truncated at the end of
+// the sequence. If ExcludeGaps is true, runs of at least MinGapLength
+// Ns are removed first and each of the remaining segments is tiled
+// separately so no window contains a gap. If Metrics is true, each
+// window has the attributes GC, N, CpG and SoftMasked (see
+// WindowMetrics).
+type WindowOptions struct {
+	Size         int
+	Step         int
+	ExcludeGaps  bool
+	MinGapLength int
+	Metrics      bool
+}
+
+// NewWindowOptions returns the defaults: non-overlapping 1000 base
+// windows that include gaps, with metrics.
+func NewWindowOptions() *WindowOptions {
+	return &WindowOptions{Size: 1000, Step: 1000, MinGapLength: 1, Metrics: true}
+}
+
+// WindowMetrics holds the sequence metrics for a window. GC is the
+// fraction of the non-N bases that are G or C, N is the fraction of
+// bases that are N, CpG is the number of CG dinucleotides and
+// SoftMasked is the fraction of bases that are lowercase.
+type WindowMetrics struct {
+	GC         float64
+	N          float64
+	CpG        int
+	SoftMasked float64
+}
+
+// NewWindowMetrics calculates the WindowMetrics for seq.
+func NewWindowMetrics(seq string) *WindowMetrics {
+	m := &WindowMetrics{}
+	if len(seq) == 0 {
+		return m
+	}
+	var gc, acgt, n, soft int
+	for i := 0; i < len(seq); i++ {
+		b := seq[i]
+		if isSoftMasked(b) {
+			soft++
+		}
+		switch toUpper(b) {
+		case 'G', 'C':
+			gc++
+			acgt++
+		case 'A', 'T':
+			acgt++
+		case 'N':
+			n++
+		}
+	}
+	if acgt > 0 {
+		m.GC = float64(gc) / float64(acgt)
+	}
+	m.N = float64(n) / float64(len(seq))
+	m.CpG = countCpG(seq).cg
+	m.SoftMasked = float64(soft) / float64(len(seq))
+	return m
+}
+
+// windows returns the 0-based half-open limits of the windows over
+// seq.
+func windows(seq string, opts *WindowOptions) [][2]int {
+	var wins [][2]int
+	if opts.Size < 1 || opts.Step < 1 {
+		return wins
+	}
+
+	segs := [][2]int{{0, len(seq)}}
+	if opts.ExcludeGaps {
+		segs = segs[:0]
+		minGap := opts.MinGapLength
+		if minGap < 1 {
+			minGap = 1
+		}
+		prev := 0
+		for _, gap := range nRuns(seq, minGap) {
+			if gap[0] > prev {
+				segs = append(segs, [2]int{prev, gap[0]})
+			}
+			prev = gap[1]
+		}
+		if prev < len(seq) {
+			segs = append(segs, [2]int{prev, len(seq)})
+		}
+	}
+
+	for _, seg := range segs {
+		for s := seg[0]; s < seg[1]; s += opts.Step {
+			e := s + opts.Size
+			if e >= seg[1] {
+				wins = append(wins, [2]int{s, seg[1]})
+				break
+			}
+			wins = append(wins, [2]int{s, e})
+		}
+	}
+	return wins
+}
+
+// Windows tiles the FastaRec and returns a gff3.Feature of Type region
+// for each window. If opts is nil, NewWindowOptions is used.
+func (r *FastaRec) Windows(opts *WindowOptions) []*gff3.Feature {
+	if opts == nil {
+		opts = NewWindowOptions()
+	}
+	var feats []*gff3.Feature
+	for _, win := range windows(r.Sequence, opts) {
+		f := gff3.NewFeature()
+		f.SeqId = r.Name
+		f.Source = `grz-window`
+		f.Type = `region`
+		f.Start = win[0] + 1
+		f.End = win[1]
+		if opts.Metrics {
+			m := NewWindowMetrics(r.Sequence[win[0]:win[1]])
+			f.Attributes[`GC`] = strconv.FormatFloat(m.GC, 'f', 3, 64)
+			f.Attributes[`N`] = strconv.FormatFloat(m.N, 'f', 3, 64)
+			f.Attributes[`CpG`] = strconv.Itoa(m.CpG)
+			f.Attributes[`SoftMasked`] = strconv.FormatFloat(m.SoftMasked, 'f', 3, 64)
+		}
+		feats = append(feats, f)
+	}
+	return feats
+}
+
+// Windows tiles every sequence in the Genome and returns the windows
+// as a sorted gff3.Features. See FastaRec.Windows.
+func (g *Genome) Windows(opts *WindowOptions) (*gff3.Features, error) {
+	fs := gff3.NewFeatures()
+	fs.Key = `genome`
+	fs.Value = g.Name
+	err := g.eachSequence(func(r *FastaRec) error {
+		fs.AddFeatures(r.Windows(opts)...)
+		return nil
+	})
+	if err != nil {
+		return fs, fmt.Errorf("genome.Genome.Windows: %w", err)
+	}
+	fs.Sort()
+	return fs, nil
+}
+
+// WriteBedGraph writes one bedGraph line per Feature to file with the
+// value taken from the named attribute, for example the GC attribute of
+// the windows from Genome.Windows. bedGraph is 0-based half-open so
+// the Feature Start is converted. bedGraph intervals must not overlap
+// so a Feature that overlaps the next Feature on the same sequence is
+// cut short where the next one starts. For sliding windows this writes
+// each window's value over the Step bases up to the start of the next
+// window, with the last window of each sequence written in full. The
+// Features must be sorted (see gff3.Features.Sort) and it is an error
+// for two of them to start at the same position or for a Feature to be
+// missing the attribute.
+func WriteBedGraph(file string, fs *gff3.Features, attribute string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	for i, feat := range fs.Features {
+		v, ok := feat.Attributes[attribute]
+		if !ok {
+			return fmt.Errorf("genome.WriteBedGraph: Feature %s:%d-%d has no %s attribute",
+				feat.SeqId, feat.Start, feat.End, attribute)
+		}
+		end := feat.End
+		if i+1 < len(fs.Features) {
+			next := fs.Features[i+1]
+			if next.SeqId == feat.SeqId && next.Start <= feat.Start {
+				return fmt.Errorf("genome.WriteBedGraph: Feature %s:%d-%d does not start after %s:%d-%d",
+					next.SeqId, next.Start, next.End, feat.SeqId, feat.Start, feat.End)
+			}
+			if next.SeqId == feat.SeqId && next.Start <= end {
+				end = next.Start - 1
+			}
+		}
+		line := feat.SeqId + "\t" + strconv.Itoa(feat.Start-1) + "\t" +
+			strconv.Itoa(end) + "\t" + v + "\n"
+		if _, err := w.WriteString(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package genome
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWindows(t *testing.T) {
+	r := NewFastaRec(">chrT")
+	r.Sequence = `ACGTacgtNNNNGGCCAT`
+
+	opts := NewWindowOptions()
+	opts.Size = 8
+	opts.Step = 8
+	feats := r.Windows(opts)
+	e := [][2]int{{1, 8}, {9, 16}, {17, 18}}
+	if len(feats) != len(e) {
+		t.Fatalf(`Windows should return %d windows but returned %d`, len(e), len(feats))
+	}
+	for i, f := range feats {
+		if f.Start != e[i][0] || f.End != e[i][1] {
+			t.Fatalf(`window %d should be %d-%d but is %s`, i, e[i][0], e[i][1], f.String())
+		}
+	}
+	a := feats[0].Attributes
+	if a[`GC`] != `0.500` || a[`N`] != `0.000` || a[`CpG`] != `2` || a[`SoftMasked`] != `0.500` {
+		t.Fatalf(`window 0 metrics incorrect: %s`, feats[0].String())
+	}
+	a = feats[1].Attributes
+	if a[`GC`] != `1.000` || a[`N`] != `0.500` || a[`CpG`] != `0` {
+		t.Fatalf(`window 1 metrics incorrect: %s`, feats[1].String())
+	}
+
+	// Windows without gaps
+	opts.Step = 4
+	opts.ExcludeGaps = true
+	opts.Metrics = false
+	feats = r.Windows(opts)
+	e = [][2]int{{1, 8}, {13, 18}}
+	if len(feats) != len(e) {
+		t.Fatalf(`Windows(ExcludeGaps) should return %d windows but returned %d`, len(e), len(feats))
+	}
+	for i, f := range feats {
+		if f.Start != e[i][0] || f.End != e[i][1] {
+			t.Fatalf(`window %d should be %d-%d but is %s`, i, e[i][0], e[i][1], f.String())
+		}
+	}
+	if len(feats[0].Attributes) != 0 {
+		t.Fatalf(`Windows without Metrics should have no attributes: %s`, feats[0].String())
+	}
+
+	// Sliding windows
+	opts.Size = 4
+	opts.Step = 2
+	feats = r.Windows(opts)
+	e = [][2]int{{1, 4}, {3, 6}, {5, 8}, {13, 16}, {15, 18}}
+	if len(feats) != len(e) {
+		t.Fatalf(`sliding Windows should return %d windows but returned %d`, len(e), len(feats))
+	}
+	for i, f := range feats {
+		if f.Start != e[i][0] || f.End != e[i][1] {
+			t.Fatalf(`window %d should be %d-%d but is %s`, i, e[i][0], e[i][1], f.String())
+		}
+	}
+}
+
+func TestWriteBedGraph(t *testing.T) {
+	g := NewGenome(`test`)
+	r := NewFastaRec(">chrT")
+	r.Sequence = `ACGTAAAAGGGG`
+	g.Sequences = append(g.Sequences, r)
+
+	opts := NewWindowOptions()
+	opts.Size = 4
+	opts.Step = 4
+	fs, err := g.Windows(opts)
+	if err != nil {
+		t.Fatalf(`Windows failed: %v`, err)
+	}
+	file := filepath.Join(t.TempDir(), "gc.bedgraph")
+	if err := WriteBedGraph(file, fs, `GC`); err != nil {
+		t.Fatalf(`WriteBedGraph failed: %v`, err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf(`unable to read %s: %v`, file, err)
+	}
+	e := "chrT\t0\t4\t0.500\nchrT\t4\t8\t0.000\nchrT\t8\t12\t1.000\n"
+	if string(b) != e {
+		t.Fatalf(`bedGraph should be %q but is %q`, e, string(b))
+	}
+
+	if err := WriteBedGraph(file, fs, `Missing`); err == nil {
+		t.Fatalf(`WriteBedGraph should fail for a missing attribute`)
+	}
+
+	// Sliding windows are cut to the Step bases before the next window
+	opts.Step = 2
+	if fs, err = g.Windows(opts); err != nil {
+		t.Fatalf(`Windows failed: %v`, err)
+	}
+	if err := WriteBedGraph(file, fs, `GC`); err != nil {
+		t.Fatalf(`WriteBedGraph failed: %v`, err)
+	}
+	if b, err = os.ReadFile(file); err != nil {
+		t.Fatalf(`unable to read %s: %v`, file, err)
+	}
+	e = "chrT\t0\t2\t0.500\nchrT\t2\t4\t0.250\nchrT\t4\t6\t0.000\n" +
+		"chrT\t6\t8\t0.500\nchrT\t8\t12\t1.000\n"
+	if string(b) != e {
+		t.Fatalf(`sliding bedGraph should be %q but is %q`, e, string(b))
+	}
+
+	fs.Features[1].Start = fs.Features[0].Start
+	if err := WriteBedGraph(file, fs, `GC`); err == nil {
+		t.Fatalf(`WriteBedGraph should fail for Features with the same start`)
+	}
+}
@@ -2,6 +2,7 @@ package genome
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/md5"
 	"fmt"
 	"io"
@@ -28,6 +29,11 @@ func Md5sum(file string) (string, error) {
 	return chk, nil
 }
 
+// Md5String returns the MD5 hash of a string as lowercase hex.
+func Md5String(s string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(s)))
+}
+
 // LinesFromFile reads a file and returns the trimmed lines.
 func LinesFromFile(file string) ([]string, error) {
 	var lines []string
@@ -64,3 +70,66 @@ func reverseBytes(b []byte) []byte {
 	}
 	return b
 }
+
+// complements maps each IUPAC nucleotide code to its complement. Case
+// is preserved and any byte not in the map is its own complement.
+var complements = map[byte]byte{
+	'A': 'T', 'C': 'G', 'G': 'C', 'T': 'A', 'U': 'A',
+	'R': 'Y', 'Y': 'R', 'S': 'S', 'W': 'W', 'K': 'M', 'M': 'K',
+	'B': 'V', 'V': 'B', 'D': 'H', 'H': 'D', 'N': 'N',
+	'a': 't', 'c': 'g', 'g': 'c', 't': 'a', 'u': 'a',
+	'r': 'y', 'y': 'r', 's': 's', 'w': 'w', 'k': 'm', 'm': 'k',
+	'b': 'v', 'v': 'b', 'd': 'h', 'h': 'd', 'n': 'n',
+}
+
+// iupacBases maps each uppercase IUPAC nucleotide code to the set of
+// uppercase DNA bases that it represents.
+var iupacBases = map[byte]string{
+	'A': "A", 'C': "C", 'G': "G", 'T': "T", 'U': "T",
+	'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+	'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG", 'N': "ACGT",
+}
+
+// reverseComplement returns a new slice holding the reverse complement
+// of b. The input is not altered. If b contains U or u it is taken to
+// be RNA and A is complemented to U rather than T.
+func reverseComplement(b []byte) []byte {
+	rna := bytes.IndexAny(b, `Uu`) >= 0
+	rc := make([]byte, len(b))
+	for i, c := range b {
+		switch {
+		case rna && c == 'A':
+			c = 'U'
+		case rna && c == 'a':
+			c = 'u'
+		default:
+			if x, ok := complements[c]; ok {
+				c = x
+			}
+		}
+		rc[len(b)-1-i] = c
+	}
+	return rc
+}
+
+// iupacMatch reports whether a sequence base matches an IUPAC code, for
+// example R matches A or G. The comparison ignores case. An N in the
+// sequence never matches because it is an unknown base rather than a
+// wildcard.
+func iupacMatch(code, base byte) bool {
+	base = toUpper(base)
+	if base == 'N' {
+		return false
+	}
+	if base == 'U' {
+		base = 'T'
+	}
+	return strings.IndexByte(iupacBases[toUpper(code)], base) >= 0
+}
+
+func toUpper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - ('a' - 'A')
+	}
+	return b
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/grendeloz/interval"
+	"github.com/grendeloz/ngs/region"
 )
 
 // The names here are based on http://gmod.org/wiki/GFF3
@@ -24,6 +25,16 @@ type Feature struct {
 	LineNumber int // Line number within the Gff3 file
 }
 
+// Region returns the location of the Feature as a region.Region. A
+// Strand other than + or - gives a Region with no strand.
+func (f *Feature) Region() *region.Region {
+	r := &region.Region{SeqId: f.SeqId, Start: f.Start, End: f.End}
+	if f.Strand == `+` || f.Strand == `-` {
+		r.Strand = f.Strand
+	}
+	return r
+}
+
 // Satisfy interval.Interval interface
 func (f *Feature) Low() int {
 	return f.Start
@@ -309,6 +320,26 @@ func (f *Feature) AttributesString() string {
 	return attrString
 }
 
+// attrEscaper percent-encodes the characters that have special meaning
+// in GFF3 column 9 as required by the GFF3 spec.
+var attrEscaper = strings.NewReplacer(
+	"%", "%25",
+	";", "%3B",
+	"=", "%3D",
+	"&", "%26",
+	",", "%2C",
+	"\t", "%09",
+	"\n", "%0A",
+	"\r", "%0D")
+
+// EscapeAttribute percent-encodes any characters in s that may not
+// appear literally in a GFF3 attribute value (; = & , tab and newline)
+// so that arbitrary text, for example a regular expression, can be
+// stored in Attributes and still be written as a valid GFF3 line.
+func EscapeAttribute(s string) string {
+	return attrEscaper.Replace(s)
+}
+
 // PrudentMerge does an interval.Compare on a pair of sorted *Feature
 // and returns a slice of non-overlapping *Feature that cover the
 // same bases as A and B but with any overlap represented as a separate
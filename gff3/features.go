@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/grendeloz/interval"
+	"github.com/grendeloz/ngs/region"
 	"github.com/grendeloz/ngs/selector"
 
 	//log "github.com/sirupsen/logrus"
@@ -358,6 +359,19 @@ func (fs *Features) selectBySeqId(sel *selector.Selector) ([]string, error) {
 	return results, nil
 }
 
+// InRegion returns a new Features holding the Feature that overlap the
+// Region. Strand is ignored. As with BySeqId, the new Features shares
+// Feature pointers with the source Features.
+func (fs *Features) InRegion(r *region.Region) *Features {
+	nfs := &Features{Key: `region`, Value: r.String(), IsSorted: fs.IsSorted}
+	for _, f := range fs.Features {
+		if r.Overlaps(f.SeqId, f.Start, f.End) {
+			nfs.Features = append(nfs.Features, f)
+		}
+	}
+	return nfs
+}
+
 // BySeqId creates a map of Features structs where each Features
 // contain Feature with the same SeqId. This can simplify a lot of other
 // operations such as Merge and Consolidate because it removes the
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"strings"
 	"testing"
+
+	"github.com/grendeloz/ngs/region"
 )
 
 // 2 SeqIds, 5 identical Feature each
@@ -69,3 +71,38 @@ func TestFeaturesSort(t *testing.T) {
 
 	// TO DO - add some actual tests!
 }
+
+func TestFeaturesInRegion(t *testing.T) {
+	s := strings.NewReader(fs1)
+	b := bufio.NewScanner(s)
+	g, err := NewFromScanner(b)
+	if err != nil {
+		t.Fatalf("NewGff3FromScanner should not have failed: %v", err)
+	}
+
+	r, _ := region.Parse(`1:18-24`)
+	fs := g.Features.InRegion(r)
+	e1 := 2
+	g1 := fs.Count()
+	if e1 != g1 {
+		t.Fatalf("InRegion(%s) should find %d Feature but found %d", r, e1, g1)
+	}
+	if fs.Features[0].Attributes[`ID`] != `2` || fs.Features[1].Attributes[`ID`] != `3` {
+		t.Fatalf("InRegion(%s) found the wrong Feature", r)
+	}
+
+	r, _ = region.Parse(`2`)
+	e2 := 5
+	g2 := g.Features.InRegion(r).Count()
+	if e2 != g2 {
+		t.Fatalf("InRegion(%s) should find %d Feature but found %d", r, e2, g2)
+	}
+
+	f := g.Features.Features[0]
+	f.Strand = `-`
+	e3 := `1:1-10:-`
+	g3 := f.Region().String()
+	if e3 != g3 {
+		t.Fatalf("Feature.Region should be %s but is %s", e3, g3)
+	}
+}
@@ -0,0 +1,217 @@
+// Package region defines a Region - a stretch of a named sequence - and
+// parses and formats regions in samtools syntax, e.g. chr1:1,000-2,000.
+// See the README.md for more details.
+package region
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Region is a stretch of the sequence named SeqId. Start and End are
+// 1-based and closed. A Start of 0 means the whole sequence and an End
+// of 0 means the region runs to the end of the sequence. Strand is +,
+// - or empty if the region has no strand.
+type Region struct {
+	SeqId  string
+	Start  int
+	End    int
+	Strand string
+}
+
+// New returns a Region after checking that the coordinates and strand
+// are valid.
+func New(seqid string, start, end int, strand string) (*Region, error) {
+	r := &Region{SeqId: seqid, Start: start, End: end, Strand: strand}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Region) validate() error {
+	if r.SeqId == `` {
+		return fmt.Errorf("region has no sequence name")
+	}
+	if r.Start < 0 || r.End < 0 {
+		return fmt.Errorf("region %s has a negative position", r)
+	}
+	if r.Start == 0 && r.End != 0 {
+		return fmt.Errorf("region %s has an end but no start", r)
+	}
+	if r.End != 0 && r.End < r.Start {
+		return fmt.Errorf("region %s ends before it starts", r)
+	}
+	switch r.Strand {
+	case ``, `+`, `-`:
+	default:
+		return fmt.Errorf("region %s has invalid strand %s", r, r.Strand)
+	}
+	return nil
+}
+
+// Parse parses a region string in samtools syntax with an optional
+// strand suffix, for example chr1, chr1:1000, chr1:1,000-2,000 or
+// chr1:1000-2000:-. A sequence name containing colons can be wrapped
+// in braces, e.g. {HLA-A*01:01}:1-100. If the text after the last
+// colon is not a valid position range, the colon is assumed to be part
+// of the sequence name, as samtools does.
+func Parse(s string) (*Region, error) {
+	s = strings.TrimSpace(s)
+	r := &Region{}
+
+	var rest string
+	if strings.HasPrefix(s, `{`) {
+		i := strings.Index(s, `}`)
+		if i < 0 {
+			return nil, fmt.Errorf("region.Parse: unmatched brace in %s", s)
+		}
+		r.SeqId, rest = s[1:i], s[i+1:]
+		if rest != `` {
+			if rest[0] != ':' {
+				return nil, fmt.Errorf("region.Parse: unexpected text after brace in %s", s)
+			}
+			rest = rest[1:]
+		}
+		switch {
+		case rest == `+` || rest == `-`:
+			r.Strand, rest = rest, ``
+		case strings.HasSuffix(rest, `:+`) || strings.HasSuffix(rest, `:-`):
+			r.Strand, rest = rest[len(rest)-1:], rest[:len(rest)-2]
+		}
+		if rest != `` {
+			start, end, ok := parsePositions(rest)
+			if !ok {
+				return nil, fmt.Errorf("region.Parse: invalid positions in %s", s)
+			}
+			r.Start, r.End = start, end
+		}
+	} else {
+		if strings.HasSuffix(s, `:+`) || strings.HasSuffix(s, `:-`) {
+			r.Strand = s[len(s)-1:]
+			s = s[:len(s)-2]
+		}
+		r.SeqId = s
+		if i := strings.LastIndex(s, `:`); i >= 0 {
+			if start, end, ok := parsePositions(s[i+1:]); ok {
+				r.SeqId, r.Start, r.End = s[:i], start, end
+			}
+		}
+	}
+
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("region.Parse: %w", err)
+	}
+	return r, nil
+}
+
+// parsePositions parses start, start- or start-end where the positions
+// may contain commas.
+func parsePositions(s string) (int, int, bool) {
+	s = strings.ReplaceAll(s, `,`, ``)
+	from, to, found := strings.Cut(s, `-`)
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 1 {
+		return 0, 0, false
+	}
+	if !found || to == `` {
+		return start, 0, true
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil || end < 1 {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// String formats the Region in samtools syntax so it can be read by
+// Parse.
+func (r *Region) String() string {
+	return r.format(strconv.Itoa)
+}
+
+// CommaString formats the Region like String but with commas as
+// thousands separators in the positions, e.g. chr1:1,000-2,000.
+func (r *Region) CommaString() string {
+	return r.format(commas)
+}
+
+func (r *Region) format(itoa func(int) string) string {
+	var sb strings.Builder
+	if strings.Contains(r.SeqId, `:`) {
+		sb.WriteString(`{` + r.SeqId + `}`)
+	} else {
+		sb.WriteString(r.SeqId)
+	}
+	if r.Start > 0 {
+		sb.WriteString(`:` + itoa(r.Start))
+		if r.End > 0 {
+			sb.WriteString(`-` + itoa(r.End))
+		}
+	}
+	if r.Strand != `` {
+		sb.WriteString(`:` + r.Strand)
+	}
+	return sb.String()
+}
+
+func commas(i int) string {
+	s := strconv.Itoa(i)
+	var sb strings.Builder
+	for j := 0; j < len(s); j++ {
+		if j > 0 && (len(s)-j)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte(s[j])
+	}
+	return sb.String()
+}
+
+// Low and High satisfy the interval.Interval interface. High is 0 for
+// a Region that runs to the end of its sequence so use Resolve first.
+func (r *Region) Low() int {
+	return r.Start
+}
+func (r *Region) High() int {
+	return r.End
+}
+
+// Length returns the number of bases in the Region or 0 if the Region
+// runs to the end of its sequence.
+func (r *Region) Length() int {
+	if r.Start == 0 || r.End == 0 {
+		return 0
+	}
+	return r.End - r.Start + 1
+}
+
+// Resolve returns a copy of the Region with Start and End filled in
+// for a sequence of the given length. It is an error for the Region to
+// extend beyond the end of the sequence.
+func (r *Region) Resolve(length int) (*Region, error) {
+	nr := *r
+	if nr.Start == 0 {
+		nr.Start = 1
+	}
+	if nr.End == 0 {
+		nr.End = length
+	}
+	if nr.Start > length || nr.End > length || nr.End < nr.Start {
+		return nil, fmt.Errorf("region.Region.Resolve: %s is outside %s (1-%d)", r, r.SeqId, length)
+	}
+	return &nr, nil
+}
+
+// Overlaps returns true if the stretch from start to end (1-based,
+// closed) of the sequence named seqid overlaps the Region. Strand is
+// ignored.
+func (r *Region) Overlaps(seqid string, start, end int) bool {
+	if seqid != r.SeqId {
+		return false
+	}
+	if r.Start == 0 {
+		return true
+	}
+	return end >= r.Start && (r.End == 0 || start <= r.End)
+}
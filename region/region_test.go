@@ -0,0 +1,95 @@
+package region
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s      string
+		seqid  string
+		start  int
+		end    int
+		strand string
+		str    string
+	}{
+		{`chr1`, `chr1`, 0, 0, ``, `chr1`},
+		{`chr1:1000`, `chr1`, 1000, 0, ``, `chr1:1000`},
+		{`chr1:1000-`, `chr1`, 1000, 0, ``, `chr1:1000`},
+		{`chr1:1,000-2,000`, `chr1`, 1000, 2000, ``, `chr1:1000-2000`},
+		{` chr1:1000-2000:- `, `chr1`, 1000, 2000, `-`, `chr1:1000-2000:-`},
+		{`chrM:+`, `chrM`, 0, 0, `+`, `chrM:+`},
+		{`HLA-A*01:01`, `HLA-A*01`, 1, 0, ``, `HLA-A*01:1`},
+		{`HLA-A*01:01:xx`, `HLA-A*01:01:xx`, 0, 0, ``, `{HLA-A*01:01:xx}`},
+		{`{HLA-A*01:01}:5-10:-`, `HLA-A*01:01`, 5, 10, `-`, `{HLA-A*01:01}:5-10:-`},
+		{`{HLA-A*01:01}:-`, `HLA-A*01:01`, 0, 0, `-`, `{HLA-A*01:01}:-`},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.s)
+		if err != nil {
+			t.Fatalf(`Parse(%s) failed: %v`, tt.s, err)
+		}
+		if r.SeqId != tt.seqid || r.Start != tt.start || r.End != tt.end || r.Strand != tt.strand {
+			t.Fatalf(`Parse(%s) should be %s,%d,%d,%s but is %s,%d,%d,%s`, tt.s,
+				tt.seqid, tt.start, tt.end, tt.strand, r.SeqId, r.Start, r.End, r.Strand)
+		}
+		if r.String() != tt.str {
+			t.Fatalf(`Parse(%s).String() should be %s but is %s`, tt.s, tt.str, r.String())
+		}
+		r2, err := Parse(r.String())
+		if err != nil || *r2 != *r {
+			t.Fatalf(`Parse(%s) does not round trip`, r.String())
+		}
+	}
+
+	bad := []string{``, `:1-10`, `chr1:10-5`, `{chr1:1-10`, `{chr1}x`, `{chr1}:a-b`}
+	for _, b := range bad {
+		if _, err := Parse(b); err == nil {
+			t.Fatalf(`Parse(%s) should fail`, b)
+		}
+	}
+}
+
+func TestCommaString(t *testing.T) {
+	r, _ := New(`chr1`, 999, 1234567, `+`)
+	e := `chr1:999-1,234,567:+`
+	if g := r.CommaString(); g != e {
+		t.Fatalf(`CommaString should be %s but is %s`, e, g)
+	}
+	if _, err := New(`chr1`, 1, 10, `x`); err == nil {
+		t.Fatalf(`New with strand x should fail`)
+	}
+}
+
+func TestResolveOverlaps(t *testing.T) {
+	r, _ := Parse(`chr1:100`)
+	if r.Length() != 0 {
+		t.Fatalf(`open-ended Region Length should be 0 but is %d`, r.Length())
+	}
+	rr, err := r.Resolve(500)
+	if err != nil {
+		t.Fatalf(`Resolve failed: %v`, err)
+	}
+	if rr.Start != 100 || rr.End != 500 || rr.Length() != 401 {
+		t.Fatalf(`Resolve(500) should be 100-500 but is %s`, rr)
+	}
+	if _, err := r.Resolve(50); err == nil {
+		t.Fatalf(`Resolve(50) should fail`)
+	}
+
+	tests := []struct {
+		seqid      string
+		start, end int
+		e          bool
+	}{
+		{`chr1`, 1, 99, false},
+		{`chr1`, 1, 100, true},
+		{`chr1`, 1000, 2000, true},
+		{`chr2`, 100, 200, false},
+	}
+	for _, tt := range tests {
+		if g := r.Overlaps(tt.seqid, tt.start, tt.end); g != tt.e {
+			t.Fatalf(`Overlaps(%s,%d,%d) should be %v but is %v`, tt.seqid, tt.start, tt.end, tt.e, g)
+		}
+	}
+}